@@ -32,7 +32,7 @@ var doc = `{
                         "TokenJWT": []
                     }
                 ],
-                "description": "Get JSON CatalogsRequest, return JSON GetCatalogsResponse",
+                "description": "Return JSON GetCatalogsResponse of catalogs of the category, or of all categories when it is empty.\nCatalogs are paginated by page and page_size, or by cursor taken from next_cursor of the previous page.\nOnly active catalogs are returned unless active or include_inactive is set.\nFilters in JSON CatalogsRequest body are deprecated and override query parameters",
                 "produces": [
                     "application/json"
                 ],
@@ -42,13 +42,82 @@ var doc = `{
                 "summary": "Get catalogs",
                 "parameters": [
                     {
-                        "description": "Catalogs",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/CatalogsRequest"
-                        }
+                        "type": "string",
+                        "description": "Category, all categories when empty",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part of the catalog name",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return only active or only inactive catalogs",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive and archived catalogs when active is not set",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "created_at",
+                            "updated_at",
+                            "value"
+                        ],
+                        "type": "string",
+                        "default": "name",
+                        "description": "Sort field",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -110,6 +179,24 @@ var doc = `{
                             "$ref": "#/definitions/Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -156,6 +243,12 @@ var doc = `{
                             "$ref": "#/definitions/Error"
                         }
                     },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -187,6 +280,19 @@ var doc = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive or archived catalog",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -202,6 +308,12 @@ var doc = `{
                             "$ref": "#/definitions/Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -216,7 +328,7 @@ var doc = `{
                         "TokenJWT": []
                     }
                 ],
-                "description": "Get id from path, return JSON DeleteCatalogResponse",
+                "description": "Get id from path, deactivate the catalog, return JSON DeleteCatalogResponse",
                 "produces": [
                     "application/json"
                 ],
@@ -246,6 +358,18 @@ var doc = `{
                             "$ref": "#/definitions/Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -255,30 +379,65 @@ var doc = `{
                 }
             }
         },
-        "/categories": {
+        "/catalog/lookup": {
             "get": {
                 "security": [
                     {
                         "TokenJWT": []
                     }
                 ],
-                "description": "Return JSON GetCategoriesResponse",
+                "description": "Return JSON GetCatalogResponse of the catalog of the category with the value, active catalog is preferred",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Catalog"
                 ],
-                "summary": "Get categories",
+                "summary": "Lookup catalog by value",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Catalog value",
+                        "name": "value",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive or archived catalog",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/GetCategoriesResponse"
+                            "$ref": "#/definitions/GetCatalogResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/Error"
                         }
@@ -286,157 +445,2009 @@ var doc = `{
                 }
             }
         },
-        "/health": {
+        "/catalog/recent": {
             "get": {
-                "description": "Return answer from server for checking what server is stay alive",
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON GetCatalogsResponse of catalogs updated since the time, most recent first.\nOnly active catalogs are returned unless include_inactive is set",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "system"
+                    "Catalog"
+                ],
+                "summary": "Get recently changed catalogs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of catalogs",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive and archived catalogs",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Health check",
-                "operationId": "health-check",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/GetCatalogsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/Error"
                         }
                     }
                 }
             }
         },
-        "/ping": {
+        "/catalog/search": {
             "get": {
-                "description": "Just ping-pong endpoint, can be used as health indicator",
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Full-text search by name, value and desc across categories. Every word of the query must\nmatch a word of the catalog exactly, as a prefix or, when fuzzy is set, with a typo.\nReturn JSON SearchCatalogsResponse, most relevant first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "testing"
+                    "Catalog"
+                ],
+                "summary": "Search catalogs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category, all categories when empty",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return only active or only inactive catalogs",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive and archived catalogs when active is not set",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Match words with typos",
+                        "name": "fuzzy",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of results",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Ping",
-                "operationId": "ping",
                 "responses": {
                     "200": {
-                        "description": "pong",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/SearchCatalogsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/Error"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "CatalogRequest": {
-            "type": "object",
-            "properties": {
-                "active": {
-                    "type": "boolean"
-                },
-                "category": {
-                    "type": "string"
-                },
-                "desc": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "value": {
-                    "type": "string"
-                }
-            }
-        },
-        "CatalogResponse": {
-            "type": "object",
-            "properties": {
-                "active": {
-                    "type": "boolean"
-                },
-                "category": {
-                    "type": "string"
-                },
-                "desc": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "value": {
-                    "type": "string"
-                }
-            }
         },
-        "CatalogsRequest": {
-            "type": "object",
-            "required": [
-                "category"
-            ],
-            "properties": {
-                "category": {
-                    "type": "string"
-                },
-                "query": {
-                    "type": "string"
-                },
-                "sorted": {
-                    "type": "boolean",
-                    "default": true
+        "/catalog/suggest": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON SuggestCatalogsResponse with active catalogs whose names start with the prefix,\nin alphabetical order and without repeated names. Letter case is ignored",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Suggest catalogs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Beginning of the catalog name",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category, all categories when empty",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 50,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SuggestCatalogsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
                 }
             }
         },
-        "DeleteCatalogResponse": {
-            "type": "object",
-            "properties": {
-                "meta": {
-                    "$ref": "#/definitions/ResponseMeta"
+        "/catalog/translations": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON ExportTranslationsResponse with names, descriptions and translations of catalogs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Export translations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category, all categories when empty",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Export inactive and archived catalogs",
+                        "name": "include_inactive",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ExportTranslationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Get JSON ImportTranslationsRequest, change translations of the catalogs, return JSON ImportTranslationsResponse.\nItems are imported one by one, failed items are listed in the response",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Import translations",
+                "parameters": [
+                    {
+                        "description": "Translations",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ImportTranslationsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ImportTranslationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/{id}/ancestors": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON GetAncestorsResponse with ancestors of the catalog from the root to its parent\nInactive catalogs are not found and inactive ancestors are skipped unless include_inactive is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Get catalog ancestors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive and archived catalogs",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GetAncestorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/{id}/archive": {
+            "post": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Hide the catalog from reads and forbid its updates until it is reactivated, return JSON UpdateCatalogResponse",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Archive catalog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UpdateCatalogResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/{id}/breadcrumbs": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON GetBreadcrumbsResponse with names from the root to the catalog\nInactive catalogs are not found and inactive ancestors are skipped unless include_inactive is set",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Get catalog breadcrumbs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive and archived catalogs",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GetBreadcrumbsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/{id}/deactivate": {
+            "post": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Hide the active catalog from reads, return JSON UpdateCatalogResponse",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Deactivate catalog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UpdateCatalogResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/{id}/purge": {
+            "post": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Hard-delete the inactive or archived catalog from Mongo and memory storage, return JSON PurgeCatalogResponse",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Purge catalog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PurgeCatalogResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/{id}/reactivate": {
+            "post": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Make the inactive or archived catalog active again, return JSON UpdateCatalogResponse",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Reactivate catalog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UpdateCatalogResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/{id}/subtree": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON GetSubtreeResponse with the catalog and its descendants, children are sorted by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Get catalog subtree",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 64,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Levels below the catalog, the whole subtree by default",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return inactive and archived catalogs",
+                        "name": "include_inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GetSubtreeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON GetCategoriesResponse ordered by sort order and name. Categories which only have catalogs\nare returned with the name and managed set to false",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Get categories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GetCategoriesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Get JSON CategoryRequest, return JSON CreateCategoryResponse. Catalogs of the category must match the schema",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Create category",
+                "parameters": [
+                    {
+                        "description": "Category",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/CreateCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/tree": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return JSON GetCategoryTreeResponse with root categories and their children sorted by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Get category tree",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GetCategoryTreeResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/{category}": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Get category name in path, return JSON GetCategoryResponse",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Get category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "ru",
+                        "description": "Locales of names and descriptions: ru, en, kk",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GetCategoryResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Get category name in path and JSON CategoryRequest, replace metadata of the category, return JSON UpdateCategoryResponse.\nCatalogs of the category must match the new schema",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Update category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UpdateCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Get category name in path, delete metadata of the category without catalogs and child categories",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Delete category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/DeleteCategoryResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/{category}/parent": {
+            "put": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Get JSON CategoryParentRequest, return JSON UpdateCategoryResponse. Empty parent makes the category a root",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Set parent category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Parent category",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CategoryParentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UpdateCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Return answer from server for checking what server is stay alive (liveness only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Health check",
+                "operationId": "health-check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ping": {
+            "get": {
+                "description": "Just ping-pong endpoint, can be used as health indicator",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "testing"
+                ],
+                "summary": "Ping",
+                "operationId": "ping",
+                "responses": {
+                    "200": {
+                        "description": "pong",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Return OK when catalogs are loaded into memory storage and replication is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Readiness check",
+                "operationId": "ready-check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/replication": {
+            "get": {
+                "description": "Return readiness, the number of stale replication operations skipped by the replicator",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Replication status",
+                "operationId": "replication-status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReplicationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/replication/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Return replication events which failed to decode or apply, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replication"
+                ],
+                "summary": "Get dead letters",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of skipped dead letters",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/GetDeadLettersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/replication/dead-letters/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Remove the dead letter without applying it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replication"
+                ],
+                "summary": "Discard dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/DeadLetterActionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/replication/dead-letters/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Publish the operation of the dead letter to the event queue again and remove the dead letter",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replication"
+                ],
+                "summary": "Replay dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/DeadLetterActionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/replication/resync": {
+            "post": {
+                "security": [
+                    {
+                        "TokenJWT": []
+                    }
+                ],
+                "description": "Compare memory storage with Mongo now and reload drifted categories",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Force resync",
+                "operationId": "replication-resync",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ResyncResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/replication/{id}": {
+            "get": {
+                "description": "Return the sequence of the last replication event applied to the catalog",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Catalog replication status",
+                "operationId": "replication-catalog-status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Catalog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReplicationCatalogResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "BreadcrumbResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "CatalogRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active is applied on create only, update keeps the lifecycle state of the catalog.",
+                    "type": "boolean"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is the hex ID of the updated catalog, it is ignored on create.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "ParentID is the hex ID of the parent catalog, empty for roots.",
+                    "type": "string"
+                },
+                "translations": {
+                    "description": "Translations of Name and Desc by locale, update keeps current translations when it is not set.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                },
+                "value": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is the expected current version for update, zero skips the check.",
+                    "type": "integer"
+                }
+            }
+        },
+        "CatalogResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "archived"
+                    ]
+                },
+                "translations": {
+                    "description": "Translations are returned by create and update, reads return Name and Desc in the negotiated locale.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                },
+                "value": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "CatalogTranslationsRequest": {
+            "type": "object",
+            "required": [
+                "id",
+                "translations"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "translations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                }
+            }
+        },
+        "CatalogTranslationsResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name and Desc are in the default locale",
+                    "type": "string"
+                },
+                "translations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "CategoryDrift": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "changed": {
+                    "type": "integer"
+                },
+                "extra": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "type": "integer"
+                }
+            }
+        },
+        "CategoryParentRequest": {
+            "type": "object",
+            "properties": {
+                "parent": {
+                    "description": "Parent is the name of the parent category, empty makes the category a root.",
+                    "type": "string"
+                }
+            }
+        },
+        "CategoryRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name is required on create, on update the name is taken from the path.",
+                    "type": "string"
+                },
+                "owner_team": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "schema": {
+                    "$ref": "#/definitions/ValueSchema"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "translations": {
+                    "description": "Translations of DisplayName as name and Description as desc by locale.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                }
+            }
+        },
+        "CategoryResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "managed": {
+                    "description": "Managed is false for categories which only have catalogs and no metadata.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_team": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "schema": {
+                    "$ref": "#/definitions/ValueSchema"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "translations": {
+                    "description": "DisplayName and Description are in the negotiated locale, Translations have all locales.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "CategoryTreeNodeResponse": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CategoryTreeNodeResponse"
+                    }
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "$ref": "#/definitions/CategoryResponse"
+                }
+            }
+        },
+        "DeadLetterActionResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "type": "object",
+                    "properties": {
+                        "action": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "DeadLetterResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "node": {
+                    "type": "string"
+                },
+                "operation": {
+                    "$ref": "#/definitions/OperationResponse"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "sequence": {
+                    "type": "integer"
+                }
+            }
+        },
+        "DeleteCatalogResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "type": "object",
+                    "properties": {
+                        "active": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "DeleteCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "type": "object",
+                    "properties": {
+                        "name": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "Error": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ExportTranslationsResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CatalogTranslationsResponse"
+                    }
+                }
+            }
+        },
+        "GetAncestorsResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CatalogResponse"
+                    }
+                }
+            }
+        },
+        "GetBreadcrumbsResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/BreadcrumbResponse"
+                    }
+                }
+            }
+        },
+        "GetCatalogResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "type": "object",
+                    "properties": {
+                        "active": {
+                            "type": "boolean"
+                        },
+                        "category": {
+                            "type": "string"
+                        },
+                        "desc": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        },
+                        "name": {
+                            "type": "string"
+                        },
+                        "parent_id": {
+                            "type": "string"
+                        },
+                        "status": {
+                            "type": "string",
+                            "enum": [
+                                "active",
+                                "inactive",
+                                "archived"
+                            ]
+                        },
+                        "translations": {
+                            "description": "Translations are returned by create and update, reads return Name and Desc in the negotiated locale.",
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/Translation"
+                            }
+                        },
+                        "value": {
+                            "type": "string"
+                        },
+                        "version": {
+                            "type": "integer"
+                        }
+                    }
+                }
+            }
+        },
+        "GetCatalogsResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CatalogResponse"
+                    }
+                }
+            }
+        },
+        "GetCategoriesResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CategoryResponse"
+                    }
+                }
+            }
+        },
+        "GetCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "$ref": "#/definitions/CategoryResponse"
+                }
+            }
+        },
+        "GetCategoryTreeResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CategoryTreeNodeResponse"
+                    }
+                }
+            }
+        },
+        "GetDeadLettersResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/DeadLetterResponse"
+                    }
+                }
+            }
+        },
+        "GetSubtreeResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "$ref": "#/definitions/TreeNodeResponse"
+                }
+            }
+        },
+        "ImportErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "ImportTranslationsRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CatalogTranslationsRequest"
+                    }
+                },
+                "replace": {
+                    "description": "Replace drops translations to locales missing in the item, otherwise only the given locales change.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "ImportTranslationsResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
                 },
                 "payload": {
                     "type": "object",
                     "properties": {
-                        "active": {
-                            "type": "boolean"
+                        "failed": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ImportErrorResponse"
+                            }
+                        },
+                        "updated": {
+                            "type": "integer"
                         }
                     }
                 }
             }
         },
-        "Error": {
+        "OperationResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "catalog": {
+                    "$ref": "#/definitions/CatalogResponse"
+                },
+                "method": {
                     "type": "string"
                 },
-                "message": {
+                "timestamp": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "integer"
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "GetCatalogResponse": {
+        "PurgeCatalogResponse": {
             "type": "object",
             "properties": {
                 "meta": {
@@ -445,52 +2456,79 @@ var doc = `{
                 "payload": {
                     "type": "object",
                     "properties": {
-                        "active": {
-                            "type": "boolean"
-                        },
-                        "category": {
-                            "type": "string"
-                        },
-                        "desc": {
-                            "type": "string"
-                        },
                         "id": {
                             "type": "string"
                         },
-                        "name": {
-                            "type": "string"
-                        },
-                        "value": {
-                            "type": "string"
+                        "purged": {
+                            "type": "boolean"
                         }
                     }
                 }
             }
         },
-        "GetCatalogsResponse": {
+        "ReconcileReport": {
             "type": "object",
             "properties": {
-                "meta": {
-                    "$ref": "#/definitions/ResponseMetaList"
+                "categories": {
+                    "type": "integer"
                 },
-                "payload": {
+                "drifted": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/CatalogResponse"
+                        "$ref": "#/definitions/CategoryDrift"
                     }
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
                 }
             }
         },
-        "GetCategoriesResponse": {
+        "ReplicationCatalogResponse": {
             "type": "object",
             "properties": {
                 "meta": {
-                    "$ref": "#/definitions/ResponseMetaList"
+                    "$ref": "#/definitions/ResponseMeta"
                 },
                 "payload": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "string"
+                        },
+                        "last_sequence": {
+                            "type": "integer"
+                        }
+                    }
+                }
+            }
+        },
+        "ReplicationResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "type": "object",
+                    "properties": {
+                        "dead_lettered": {
+                            "type": "integer"
+                        },
+                        "drifted_categories": {
+                            "type": "integer"
+                        },
+                        "last_reconcile": {
+                            "$ref": "#/definitions/ReconcileReport"
+                        },
+                        "ready": {
+                            "type": "boolean"
+                        },
+                        "stale_operations": {
+                            "type": "integer"
+                        }
                     }
                 }
             }
@@ -504,6 +2542,9 @@ var doc = `{
                 "current_page": {
                     "type": "integer"
                 },
+                "next_cursor": {
+                    "type": "string"
+                },
                 "node_id": {
                     "type": "string"
                 },
@@ -518,6 +2559,171 @@ var doc = `{
                 }
             }
         },
+        "ResyncResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "$ref": "#/definitions/ReconcileReport"
+                }
+            }
+        },
+        "SearchCatalogResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "score": {
+                    "description": "Score is the relevance of the catalog, higher is better",
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "archived"
+                    ]
+                },
+                "translations": {
+                    "description": "Translations are returned by create and update, reads return Name and Desc in the negotiated locale.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                },
+                "value": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "SearchCatalogsResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SearchCatalogResponse"
+                    }
+                }
+            }
+        },
+        "SuggestCatalogsResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMetaList"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SuggestionResponse"
+                    }
+                }
+            }
+        },
+        "SuggestionResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "Translation": {
+            "type": "object",
+            "properties": {
+                "desc": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "TreeNodeResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/TreeNodeResponse"
+                    }
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "inactive",
+                        "archived"
+                    ]
+                },
+                "translations": {
+                    "description": "Translations are returned by create and update, reads return Name and Desc in the negotiated locale.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/Translation"
+                    }
+                },
+                "value": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
         "UpdateCatalogResponse": {
             "type": "object",
             "properties": {
@@ -542,12 +2748,72 @@ var doc = `{
                         "name": {
                             "type": "string"
                         },
+                        "parent_id": {
+                            "type": "string"
+                        },
+                        "status": {
+                            "type": "string",
+                            "enum": [
+                                "active",
+                                "inactive",
+                                "archived"
+                            ]
+                        },
+                        "translations": {
+                            "description": "Translations are returned by create and update, reads return Name and Desc in the negotiated locale.",
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/Translation"
+                            }
+                        },
                         "value": {
                             "type": "string"
+                        },
+                        "version": {
+                            "type": "integer"
                         }
                     }
                 }
             }
+        },
+        "UpdateCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/ResponseMeta"
+                },
+                "payload": {
+                    "$ref": "#/definitions/CategoryResponse"
+                }
+            }
+        },
+        "ValueSchema": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "enum": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "json_schema": {
+                    "type": "object"
+                },
+                "pattern": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "regex",
+                        "enum",
+                        "json_schema"
+                    ]
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -571,7 +2837,7 @@ type swaggerInfo struct {
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = swaggerInfo{
 	Version:     "0.1",
-	Host:        "127.0.0.1:8091",
+	Host:        "127.0.0.1:8090",
 	BasePath:    "/",
 	Schemes:     []string{},
 	Title:       "Swagger Catalogs Service",
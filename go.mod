@@ -6,16 +6,23 @@ require (
 	github.com/afiskon/promtail-client v0.0.0-20190305142237-506f3f921e9c
 	github.com/gin-gonic/gin v1.7.7
 	github.com/go-kit/kit v0.12.0
+	github.com/go-playground/validator/v10 v10.4.1
 	github.com/hashicorp/vault/api v1.3.0
 	github.com/joho/godotenv v1.4.0
 	github.com/matoous/go-nanoid/v2 v2.0.0
 	github.com/mitchellh/mapstructure v1.4.2
 	github.com/nats-io/nats-streaming-server v0.23.2 // indirect
+	github.com/nats-io/nats.go v1.13.1-0.20211018182449-f2416a8b1483
 	github.com/nats-io/stan.go v0.10.2
 	github.com/opentracing/opentracing-go v1.2.0
+	github.com/segmentio/kafka-go v0.4.28
 	github.com/swaggo/files v0.0.0-20210815190702-a29dd2bc99b2
 	github.com/swaggo/gin-swagger v1.3.3
+	github.com/swaggo/swag v1.7.4
 	github.com/uber/jaeger-client-go v2.30.0+incompatible
 	github.com/uber/jaeger-lib v2.4.1+incompatible
+	github.com/xeipuuv/gojsonschema v1.2.0
 	go.mongodb.org/mongo-driver v1.8.1
+	golang.org/x/text v0.3.7
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redacted = "******"
+
+// AppConfig is the typed service configuration. It is loaded once on startup by Load.
+//
+// Fields are described with tags:
+//
+//	env      - name of the environment variable, `<env>_SECURE` is supported as in Configuration.Get
+//	default  - value used when the variable is empty
+//	required - the value must not be empty after defaults are applied
+//	secret   - the value is redacted in Report, values read from a secret provider are always redacted
+type AppConfig struct {
+	HTTP        HTTPServer
+	Shutdown    Shutdown
+	Reload      Reload
+	Mongo       Mongo
+	Queue       Queue
+	Outbox      Outbox
+	Replication Replication
+	Loki        Loki
+	Jaeger      Jaeger
+
+	report []ReportEntry
+}
+
+// Shutdown holds graceful shutdown settings. DrainTimeout bounds draining of HTTP requests, PhaseTimeout
+// bounds each of the following phases.
+type Shutdown struct {
+	DrainTimeout time.Duration `env:"SHUTDOWN_DRAIN_TIMEOUT" default:"10s"`
+	PhaseTimeout time.Duration `env:"SHUTDOWN_PHASE_TIMEOUT" default:"5s"`
+}
+
+// Reload holds configuration reload settings.
+type Reload struct {
+	Interval time.Duration `env:"CONFIG_RELOAD_INTERVAL" default:"0s"`
+}
+
+// Mongo holds database connection settings.
+type Mongo struct {
+	Host     string `env:"MONGO_HOST" required:"true"`
+	Username string `env:"MONGO_USERNAME" required:"true"`
+	Password string `env:"MONGO_PASSWORD" required:"true" secret:"true"`
+	Database string `env:"MONGO_DATABASE" required:"true"`
+}
+
+// Queue holds event queue settings. Backend is one of "stan", "jetstream", "kafka" or "memory".
+type Queue struct {
+	Backend   string `env:"EVENT_QUEUE_BACKEND" default:"stan"`
+	URL       string `env:"EVENT_QUEUE_URL" default:"nats://127.0.0.1:4222"`
+	ClusterID string `env:"EVENT_QUEUE_CLUSTER_ID"`
+	Subject   string `env:"EVENT_QUEUE_SUBJECT"`
+	// Stream is the JetStream stream name.
+	Stream string `env:"EVENT_QUEUE_STREAM" default:"CATALOGS"`
+	// Durable is the JetStream durable consumer or the Kafka consumer group of the node, it must be
+	// unique per node and stable across restarts. Empty means the host name.
+	Durable string `env:"EVENT_QUEUE_DURABLE"`
+	// Brokers is a comma separated list of Kafka brokers. Subject is used as the topic.
+	Brokers string `env:"EVENT_QUEUE_BROKERS"`
+}
+
+// Outbox holds settings of the relay publishing outbox events to the event queue.
+type Outbox struct {
+	PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	BatchSize    int           `env:"OUTBOX_BATCH_SIZE" default:"100"`
+	MaxBackoff   time.Duration `env:"OUTBOX_MAX_BACKOFF" default:"1m"`
+	LockTTL      time.Duration `env:"OUTBOX_LOCK_TTL" default:"30s"`
+	Retention    time.Duration `env:"OUTBOX_RETENTION" default:"168h"`
+}
+
+// Replication holds settings of the memory storage replication. Source is "queue" to apply events
+// published by the service or "changestream" to follow all writes to the catalogs collection.
+type Replication struct {
+	Source string `env:"REPLICATION_SOURCE" default:"queue"`
+	// ResumeID is the key of the persisted change stream resume token.
+	ResumeID string `env:"REPLICATION_RESUME_ID" default:"catalogs"`
+	// ReconcileInterval is how often memory storage is compared with Mongo, zero disables it.
+	ReconcileInterval time.Duration `env:"REPLICATION_RECONCILE_INTERVAL" default:"5m"`
+	// SnapshotPath is the memory storage snapshot file, empty disables snapshots. Snapshots are used
+	// only with the stan queue, which can replay events from the snapshot sequence.
+	SnapshotPath     string        `env:"REPLICATION_SNAPSHOT_PATH"`
+	SnapshotInterval time.Duration `env:"REPLICATION_SNAPSHOT_INTERVAL" default:"1m"`
+	// MaxRedeliveries is how many times an event which fails to apply is delivered before it is
+	// stored as a dead letter.
+	MaxRedeliveries int `env:"REPLICATION_MAX_REDELIVERIES" default:"5"`
+	// SnapshotMaxAge is the age after which a snapshot is ignored and catalogs are loaded from Mongo.
+	SnapshotMaxAge time.Duration `env:"REPLICATION_SNAPSHOT_MAX_AGE" default:"1h"`
+}
+
+// Loki holds log collector settings.
+type Loki struct {
+	Host string `env:"LOKI_AGENT_HOST" required:"true"`
+	Port string `env:"LOKI_AGENT_PORT" default:"3100"`
+}
+
+// Jaeger holds tracing agent settings.
+type Jaeger struct {
+	Host string `env:"JAEGER_AGENT_HOST" default:"localhost"`
+	Port string `env:"JAEGER_AGENT_PORT" default:"6831"`
+}
+
+// ReportEntry describes one loaded configuration value.
+type ReportEntry struct {
+	Key    string
+	Value  string
+	Source Source
+}
+
+// ValidationError lists every missing or invalid configuration key.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(e.Problems, "; "))
+}
+
+// Load reads AppConfig from configuration, applies defaults, parses durations and numbers and
+// checks required values. All problems are returned in a single *ValidationError.
+func Load(c *Configuration) (*AppConfig, error) {
+	res := &AppConfig{}
+	verr := &ValidationError{}
+
+	res.report = loadStruct(c, reflect.ValueOf(res).Elem(), verr)
+	res.validate(verr)
+
+	if len(verr.Problems) > 0 {
+		return nil, verr
+	}
+
+	return res, nil
+}
+
+// Report returns loaded values with their sources. Secret values are redacted.
+func (a *AppConfig) Report() []ReportEntry {
+	return a.report
+}
+
+// ReportLines returns Report formatted for logging.
+func (a *AppConfig) ReportLines() []string {
+	lines := make([]string, 0, len(a.report))
+	for _, entry := range a.report {
+		lines = append(lines, fmt.Sprintf("%s=%q (%s)", entry.Key, entry.Value, entry.Source))
+	}
+
+	return lines
+}
+
+// validate checks values which depend on each other.
+func (a *AppConfig) validate(verr *ValidationError) {
+	switch a.Queue.Backend {
+	case "stan":
+		if a.Queue.ClusterID == "" {
+			verr.Problems = append(verr.Problems, "EVENT_QUEUE_CLUSTER_ID: required for stan backend")
+		}
+		fallthrough
+	case "jetstream", "kafka":
+		if a.Queue.Subject == "" {
+			verr.Problems = append(verr.Problems, "EVENT_QUEUE_SUBJECT: required for "+a.Queue.Backend+" backend")
+		}
+		if a.Queue.Backend == "kafka" && a.Queue.Brokers == "" {
+			verr.Problems = append(verr.Problems, "EVENT_QUEUE_BROKERS: required for kafka backend")
+		}
+	case "memory":
+	default:
+		verr.Problems = append(verr.Problems, fmt.Sprintf("EVENT_QUEUE_BACKEND: unknown backend %q", a.Queue.Backend))
+	}
+
+	switch a.Replication.Source {
+	case "queue", "changestream":
+	default:
+		verr.Problems = append(verr.Problems, fmt.Sprintf("REPLICATION_SOURCE: unknown source %q", a.Replication.Source))
+	}
+}
+
+// loadStruct fills tagged fields of v and nested structs.
+func loadStruct(c *Configuration, v reflect.Value, verr *ValidationError) []ReportEntry {
+	var report []ReportEntry
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				report = append(report, loadStruct(c, v.Field(i), verr)...)
+			}
+			continue
+		}
+
+		value, source, err := c.Lookup(key)
+		if err != nil {
+			verr.Problems = append(verr.Problems, fmt.Sprintf("%s: %s", key, err.Error()))
+			continue
+		}
+
+		if value == "" {
+			value = field.Tag.Get("default")
+		}
+
+		if value == "" && field.Tag.Get("required") == "true" {
+			verr.Problems = append(verr.Problems, fmt.Sprintf("%s: required", key))
+			continue
+		}
+
+		if err := setField(v.Field(i), value); err != nil {
+			verr.Problems = append(verr.Problems, fmt.Sprintf("%s: %s", key, err.Error()))
+			continue
+		}
+
+		if (field.Tag.Get("secret") == "true" || source.Secure()) && value != "" {
+			value = redacted
+		}
+
+		report = append(report, ReportEntry{Key: key, Value: value, Source: source})
+	}
+
+	return report
+}
+
+// setField parses value according to the field type.
+func setField(field reflect.Value, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		field.SetInt(int64(d))
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
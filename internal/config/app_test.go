@@ -0,0 +1,239 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapProvider serves secrets from a map.
+type mapProvider map[string]string
+
+func (p mapProvider) Get(key string) (string, error) {
+	v, ok := p[key]
+	if !ok {
+		return "", errors.New("key not found")
+	}
+	return v, nil
+}
+
+func (p mapProvider) Name() string {
+	return "memory"
+}
+
+// setenv sets the variable for the test and restores its previous value.
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+
+	prev, ok := os.LookupEnv(key)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+			return
+		}
+		os.Unsetenv(key)
+	})
+
+	os.Setenv(key, value)
+}
+
+// clearEnv empties every variable of AppConfig and its `<key>_SECURE` variable for the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	var clear func(rt reflect.Type)
+	clear = func(rt reflect.Type) {
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if key, ok := field.Tag.Lookup("env"); ok {
+				setenv(t, key, "")
+				setenv(t, key+"_SECURE", "")
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct && field.PkgPath == "" {
+				clear(field.Type)
+			}
+		}
+	}
+	clear(reflect.TypeOf(AppConfig{}))
+}
+
+// setRequired sets every required variable.
+func setRequired(t *testing.T) {
+	t.Helper()
+
+	for key, value := range map[string]string{
+		"MONGO_HOST":             "mongo:27017",
+		"MONGO_USERNAME":         "catalogs",
+		"MONGO_PASSWORD":         "pass",
+		"MONGO_DATABASE":         "dev",
+		"LOKI_AGENT_HOST":        "loki",
+		"EVENT_QUEUE_CLUSTER_ID": "cluster",
+		"EVENT_QUEUE_SUBJECT":    "catalogs",
+	} {
+		setenv(t, key, value)
+	}
+}
+
+// reported returns the report entry of the key.
+func reported(t *testing.T, cfg *AppConfig, key string) ReportEntry {
+	t.Helper()
+
+	for _, entry := range cfg.Report() {
+		if entry.Key == key {
+			return entry
+		}
+	}
+	t.Fatalf("%s is not reported", key)
+
+	return ReportEntry{}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+
+	cfg, err := Load(NewConfig(mapProvider{}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Queue.Backend != "stan" || cfg.Queue.Stream != "CATALOGS" || cfg.Loki.Port != "3100" {
+		t.Fatalf("defaults are not applied: %+v %+v", cfg.Queue, cfg.Loki)
+	}
+	if cfg.Shutdown.DrainTimeout != 10*time.Second || cfg.Replication.ReconcileInterval != 5*time.Minute {
+		t.Fatalf("default durations are not applied: %+v %+v", cfg.Shutdown, cfg.Replication)
+	}
+	if cfg.Mongo.Host != "mongo:27017" {
+		t.Fatalf("MONGO_HOST = %q", cfg.Mongo.Host)
+	}
+
+	if entry := reported(t, cfg, "LOKI_AGENT_PORT"); entry.Source != SourceDefault || entry.Value != "3100" {
+		t.Fatalf("LOKI_AGENT_PORT reported as %+v", entry)
+	}
+	if entry := reported(t, cfg, "MONGO_HOST"); entry.Source != SourceEnv || entry.Value != "mongo:27017" {
+		t.Fatalf("MONGO_HOST reported as %+v", entry)
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+	setenv(t, "MONGO_PASSWORD", "")
+	setenv(t, "MONGO_PASSWORD_SECURE", "mongo:password")
+	setenv(t, "MONGO_USERNAME_SECURE", "mongo:user")
+
+	cfg, err := Load(NewConfig(mapProvider{"mongo:password": "secret", "mongo:user": "catalogs"}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Mongo.Password != "secret" || cfg.Mongo.Username != "catalogs" {
+		t.Fatalf("secrets are not loaded: %+v", cfg.Mongo)
+	}
+
+	// Secret tagged values and values of the provider are redacted
+	if entry := reported(t, cfg, "MONGO_PASSWORD"); entry.Value != redacted || entry.Source != "memory" {
+		t.Fatalf("MONGO_PASSWORD reported as %+v", entry)
+	}
+	if entry := reported(t, cfg, "MONGO_USERNAME"); entry.Value != redacted || entry.Source != "memory" {
+		t.Fatalf("MONGO_USERNAME reported as %+v", entry)
+	}
+	for _, line := range cfg.ReportLines() {
+		if strings.Contains(line, "secret") {
+			t.Fatalf("report line %q shows a secret", line)
+		}
+	}
+
+	setenv(t, "MONGO_PASSWORD_SECURE", "")
+	setenv(t, "MONGO_PASSWORD", "plain")
+	cfg, err = Load(NewConfig(mapProvider{"mongo:user": "catalogs"}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if entry := reported(t, cfg, "MONGO_PASSWORD"); entry.Value != redacted || entry.Source != SourceEnv {
+		t.Fatalf("MONGO_PASSWORD from ENV reported as %+v", entry)
+	}
+}
+
+func TestLoadValidationError(t *testing.T) {
+	clearEnv(t)
+	setenv(t, "MONGO_HOST", "mongo:27017")
+	setenv(t, "MONGO_PASSWORD_SECURE", "mongo:missing")
+	setenv(t, "SHUTDOWN_DRAIN_TIMEOUT", "ten seconds")
+	setenv(t, "OUTBOX_BATCH_SIZE", "many")
+	setenv(t, "EVENT_QUEUE_BACKEND", "rabbit")
+	setenv(t, "REPLICATION_SOURCE", "oplog")
+
+	cfg, err := Load(NewConfig(mapProvider{}))
+	if cfg != nil {
+		t.Fatal("Load returned a configuration with an error")
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Load error %v is not a *ValidationError", err)
+	}
+
+	// Every problem is reported at once
+	want := []string{
+		"MONGO_USERNAME: required",
+		"MONGO_PASSWORD: provider get",
+		"MONGO_DATABASE: required",
+		"LOKI_AGENT_HOST: required",
+		`SHUTDOWN_DRAIN_TIMEOUT: invalid duration "ten seconds"`,
+		`OUTBOX_BATCH_SIZE: invalid integer "many"`,
+		`EVENT_QUEUE_BACKEND: unknown backend "rabbit"`,
+		`REPLICATION_SOURCE: unknown source "oplog"`,
+	}
+	if len(verr.Problems) != len(want) {
+		t.Fatalf("problems = %q, want %d problems", verr.Problems, len(want))
+	}
+	for _, problem := range want {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %q does not report %q", err, problem)
+		}
+	}
+}
+
+func TestLoadBackendRequirements(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    []string
+	}{
+		{"stan", []string{"EVENT_QUEUE_CLUSTER_ID", "EVENT_QUEUE_SUBJECT"}},
+		{"jetstream", []string{"EVENT_QUEUE_SUBJECT"}},
+		{"kafka", []string{"EVENT_QUEUE_SUBJECT", "EVENT_QUEUE_BROKERS"}},
+		{"memory", nil},
+	}
+
+	for _, tt := range tests {
+		clearEnv(t)
+		setRequired(t)
+		setenv(t, "EVENT_QUEUE_CLUSTER_ID", "")
+		setenv(t, "EVENT_QUEUE_SUBJECT", "")
+		setenv(t, "EVENT_QUEUE_BACKEND", tt.backend)
+
+		_, err := Load(NewConfig(mapProvider{}))
+		if len(tt.want) == 0 {
+			if err != nil {
+				t.Errorf("%s: Load: %v", tt.backend, err)
+			}
+			continue
+		}
+
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Problems) != len(tt.want) {
+			t.Errorf("%s: Load error %v, want %d problems", tt.backend, err, len(tt.want))
+			continue
+		}
+		for i, key := range tt.want {
+			if !strings.HasPrefix(verr.Problems[i], key+":") {
+				t.Errorf("%s: problem %q, want %s", tt.backend, verr.Problems[i], key)
+			}
+		}
+	}
+}
@@ -4,23 +4,116 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"sync"
 )
 
+// Source describes where a configuration value came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceEnv     Source = "env"
+	SourceFile    Source = ".env file"
+	SourceVault   Source = "vault"
+)
+
+// Secure returns true if the value was read through a secret provider.
+func (s Source) Secure() bool {
+	return s != SourceDefault && s != SourceEnv && s != SourceFile
+}
+
+// fileKeys holds the keys loaded into ENV from the env file by LoadConfig.
+var fileKeys = struct {
+	sync.RWMutex
+	keys map[string]bool
+}{keys: make(map[string]bool)}
+
 // Provider ...
 type Provider interface {
 	Get(key string) (string, error)
 }
 
+// Invalidator is a Provider with cached secrets. Invalidate drops the cache, it fails when secrets
+// can't be read again.
+type Invalidator interface {
+	Invalidate() error
+}
+
+// ErrorReporter is a Provider which fails in background, e.g. renewing its credentials.
+// SetErrorHandler sets the function receiving such errors.
+type ErrorReporter interface {
+	SetErrorHandler(fn func(err error))
+}
+
+// NamedProvider is a Provider that reports its name as the value source.
+type NamedProvider interface {
+	Provider
+	Name() string
+}
+
 // Configuration ...
 type Configuration struct {
 	provider Provider
 }
 
-// LoadConfig read the env filename and load it into ENV for this process.
+// LoadConfig read the env filename and load it into ENV for this process. Variables already present
+// in ENV are not overridden.
 func LoadConfig(filename string) error {
-	if err := godotenv.Load(filename); err != nil {
+	values, err := godotenv.Read(filename)
+	if err != nil {
 		return nil //fmt.Errorf("loading env var file: %w", err)
 	}
+
+	fileKeys.Lock()
+	defer fileKeys.Unlock()
+
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+		fileKeys.keys[key] = true
+	}
+
+	return nil
+}
+
+// ReloadConfig reads the env filename again. Variables which were loaded from the file are updated
+// or removed, other ENV variables are not overridden.
+func ReloadConfig(filename string) error {
+	values, err := godotenv.Read(filename)
+	if err != nil {
+		return fmt.Errorf("loading env var file: %w", err)
+	}
+
+	fileKeys.Lock()
+	defer fileKeys.Unlock()
+
+	for key := range fileKeys.keys {
+		if _, ok := values[key]; ok {
+			continue
+		}
+
+		if err := os.Unsetenv(key); err != nil {
+			return fmt.Errorf("unset %s: %w", key, err)
+		}
+		delete(fileKeys.keys, key)
+	}
+
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok && !fileKeys.keys[key] {
+			continue
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+		fileKeys.keys[key] = true
+	}
+
 	return nil
 }
 
@@ -34,16 +127,48 @@ func NewConfig(provider Provider) *Configuration {
 // Get returns the value from environment variable `<key>`. When an environment variable `<key>_SECURE` exists
 // the provider is used for getting the value.
 func (c *Configuration) Get(key string) (string, error) {
-	res := os.Getenv(key)
+	res, _, err := c.Lookup(key)
+	return res, err
+}
+
+// Invalidate drops secrets cached by the provider, so they are read again.
+func (c *Configuration) Invalidate() error {
+	if inv, ok := c.provider.(Invalidator); ok {
+		return inv.Invalidate()
+	}
+
+	return nil
+}
+
+// Lookup works like Get and also reports the source of the value. Empty values are reported
+// with SourceDefault.
+func (c *Configuration) Lookup(key string) (string, Source, error) {
 	valSecret := os.Getenv(fmt.Sprintf("%s_SECURE", key))
 
 	if valSecret != "" {
+		source := SourceVault
+		if named, ok := c.provider.(NamedProvider); ok {
+			source = Source(named.Name())
+		}
+
 		valSecretRes, err := c.provider.Get(valSecret)
 		if err != nil {
-			return "", fmt.Errorf("provider get: %w", err)
+			return "", source, fmt.Errorf("provider get: %w", err)
 		}
-		res = valSecretRes
+		return valSecretRes, source, nil
+	}
+
+	res := os.Getenv(key)
+	if res == "" {
+		return "", SourceDefault, nil
+	}
+
+	fileKeys.RLock()
+	defer fileKeys.RUnlock()
+
+	if fileKeys.keys[key] {
+		return res, SourceFile, nil
 	}
 
-	return res, nil
+	return res, SourceEnv, nil
 }
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"net"
+	"time"
+)
+
+// HTTPServer holds listen address and limits of the HTTP server.
+type HTTPServer struct {
+	Host           string        `env:"HTTP_HOST"`
+	Port           string        `env:"HTTP_PORT" default:"8090"`
+	ReadTimeout    time.Duration `env:"HTTP_READ_TIMEOUT" default:"10s"`
+	WriteTimeout   time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"10s"`
+	IdleTimeout    time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"60s"`
+	MaxHeaderBytes int           `env:"HTTP_MAX_HEADER_BYTES" default:"1048576"`
+}
+
+// Address returns listen address in the `host:port` form.
+func (s HTTPServer) Address() string {
+	return net.JoinHostPort(s.Host, s.Port)
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Subscriber is notified with the previous and the new configuration after a change.
+type Subscriber func(prev, next *AppConfig)
+
+// Watcher reloads AppConfig on schedule or on SIGHUP and notifies subscribers when it changes.
+type Watcher struct {
+	conf     *Configuration
+	envFile  string
+	interval time.Duration
+
+	mu          sync.Mutex
+	current     *AppConfig
+	subscribers []Subscriber
+}
+
+// NewWatcher creates a watcher for the loaded configuration. Zero interval disables scheduled reloads.
+func NewWatcher(conf *Configuration, envFile string, current *AppConfig, interval time.Duration) *Watcher {
+	return &Watcher{
+		conf:     conf,
+		envFile:  envFile,
+		interval: interval,
+		current:  current,
+	}
+}
+
+// Subscribe adds a subscriber. Subscribers are called in the order they were added.
+func (w *Watcher) Subscribe(fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Current returns the last loaded configuration.
+func (w *Watcher) Current() *AppConfig {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.current
+}
+
+// Reload reads the env file and secrets again. Subscribers are notified only when the configuration
+// has changed. An invalid configuration is rejected and the current one is kept.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.envFile != "" {
+		if err := ReloadConfig(w.envFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := w.conf.Invalidate(); err != nil {
+		return fmt.Errorf("invalidate secrets: %w", err)
+	}
+
+	next, err := Load(w.conf)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	if reflect.DeepEqual(w.current, next) {
+		return nil
+	}
+
+	prev := w.current
+	w.current = next
+
+	for _, fn := range w.subscribers {
+		fn(prev, next)
+	}
+
+	return nil
+}
+
+// Run reloads configuration until ctx is done. Reload errors are passed to onError.
+func (w *Watcher) Run(ctx context.Context, onError func(err error)) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		tc := time.NewTicker(w.interval)
+		defer tc.Stop()
+		tick = tc.C
+	}
+
+	for {
+		select {
+		case <-sighup:
+		case <-tick:
+		case <-ctx.Done():
+			return
+		}
+
+		if err := w.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
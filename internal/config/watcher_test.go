@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// reloadingProvider is a mapProvider which fails to invalidate when err is set.
+type reloadingProvider struct {
+	mapProvider
+	err error
+}
+
+func (p *reloadingProvider) Invalidate() error {
+	return p.err
+}
+
+func TestWatcherReload(t *testing.T) {
+	clearEnv(t)
+	setRequired(t)
+
+	provider := &reloadingProvider{mapProvider: mapProvider{}}
+	conf := NewConfig(provider)
+	current, err := Load(conf)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	w := NewWatcher(conf, "", current, 0)
+
+	var notified []*AppConfig
+	w.Subscribe(func(prev, next *AppConfig) {
+		notified = append(notified, next)
+	})
+
+	// An unchanged configuration does not notify subscribers
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(notified) != 0 {
+		t.Fatalf("subscribers notified %d times without a change", len(notified))
+	}
+
+	setenv(t, "LOKI_AGENT_PORT", "3200")
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(notified) != 1 || w.Current().Loki.Port != "3200" {
+		t.Fatalf("change is not applied: notified %d times, port %q", len(notified), w.Current().Loki.Port)
+	}
+
+	// Secrets which can't be read again reject the reload
+	provider.err = errors.New("parse json: unexpected end of JSON input")
+	setenv(t, "LOKI_AGENT_PORT", "3300")
+	if err := w.Reload(); err == nil || !strings.Contains(err.Error(), "parse json") {
+		t.Fatalf("Reload error = %v, want the invalidate error", err)
+	}
+	if len(notified) != 1 || w.Current().Loki.Port != "3200" {
+		t.Fatal("configuration changed although secrets were not reloaded")
+	}
+
+	// An invalid configuration is rejected
+	provider.err = nil
+	setenv(t, "EVENT_QUEUE_BACKEND", "rabbit")
+	var verr *ValidationError
+	if err := w.Reload(); !errors.As(err, &verr) {
+		t.Fatalf("Reload error = %v, want a *ValidationError", err)
+	}
+	if w.Current().Queue.Backend != "stan" {
+		t.Fatal("invalid configuration was applied")
+	}
+}
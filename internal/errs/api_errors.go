@@ -59,6 +59,14 @@ func NewUnauthorizedError(message string) *ApiErr {
 	}
 }
 
+func NewConflictError(message string) *ApiErr {
+	return &ApiErr{
+		message: message,
+		status:  http.StatusConflict,
+		err:     "conflict_error",
+	}
+}
+
 func NewUnprocessableEntityError(message string) *ApiErr {
 	return &ApiErr{
 		message: message,
@@ -75,6 +83,14 @@ func NewNoContentError(message string) *ApiErr {
 	}
 }
 
+func NewServiceUnavailableError(message string) *ApiErr {
+	return &ApiErr{
+		message: message,
+		status:  http.StatusServiceUnavailable,
+		err:     "service_unavailable_error",
+	}
+}
+
 func NewTeapotError(message string) *ApiErr {
 	return &ApiErr{
 		message: message,
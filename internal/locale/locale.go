@@ -0,0 +1,91 @@
+// Package locale negotiates the locale of a request and resolves fallbacks of translations.
+package locale
+
+import (
+	"context"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+const (
+	Russian = "ru"
+	English = "en"
+	Kazakh  = "kk"
+
+	// Default is the locale of Name and Desc of catalogs and of DisplayName and Description of categories.
+	Default = Russian
+)
+
+type contextKey struct{}
+
+var (
+	// supported are ordered by preference, the first one is used when nothing matches.
+	supported = []string{Russian, English, Kazakh}
+
+	matcher = language.NewMatcher([]language.Tag{
+		language.Russian,
+		language.English,
+		language.Make(Kazakh),
+	})
+
+	// fallbacks lists locales tried after the requested one, before the default texts.
+	fallbacks = map[string][]string{
+		Kazakh: {Russian},
+	}
+)
+
+// Supported reports whether translations to the locale are accepted.
+func Supported(locale string) bool {
+	for _, l := range supported {
+		if l == locale {
+			return true
+		}
+	}
+
+	return false
+}
+
+// All returns the supported locales.
+func All() []string {
+	return append([]string(nil), supported...)
+}
+
+// Negotiate returns the supported locale best matching the Accept-Language header, Default when none matches.
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+
+	return supported[index]
+}
+
+// Chain returns the locale followed by its fallbacks. Texts in the Default locale are the last fallback
+// and are not listed.
+func Chain(locale string) []string {
+	return append([]string{locale}, fallbacks[locale]...)
+}
+
+// Collator returns a new collator of the locale. A collator is not safe for concurrent use.
+func Collator(locale string) *collate.Collator {
+	return collate.New(language.Make(locale), collate.IgnoreCase)
+}
+
+// NewContext returns the context carrying the locale.
+func NewContext(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale of the context, Default when it has none.
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok && locale != "" {
+		return locale
+	}
+
+	return Default
+}
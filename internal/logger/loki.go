@@ -7,21 +7,11 @@ import (
 	"time"
 )
 
-func NewLogger(sourceName, jobName string, configuration *config.Configuration) (client promtail.Client, err error) {
+func NewLogger(sourceName, jobName string, cfg config.Loki) (client promtail.Client, err error) {
 	labels := "{source=\"" + sourceName + "\",job=\"" + jobName + "\"}"
 
-	host, err := configuration.Get("LOKI_AGENT_HOST")
-	if err != nil {
-		return nil, err
-	}
-
-	port, err := configuration.Get("LOKI_AGENT_PORT")
-	if err != nil {
-		return nil, err
-	}
-
 	conf := promtail.ClientConfig{
-		PushURL:            fmt.Sprintf("http://%v:%v/api/prom/push", host, port),
+		PushURL:            fmt.Sprintf("http://%v:%v/api/prom/push", cfg.Host, cfg.Port),
 		Labels:             labels,
 		BatchWait:          5 * time.Second,
 		BatchEntriesNumber: 10000,
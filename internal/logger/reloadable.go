@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"sync"
+)
+
+// Reloadable is a promtail.Client which can be re-pointed to another Loki agent at runtime.
+type Reloadable struct {
+	sourceName string
+	jobName    string
+
+	mu     sync.RWMutex
+	client promtail.Client
+}
+
+// NewReloadableLogger creates a logger which can be reconfigured with Reconfigure.
+func NewReloadableLogger(sourceName, jobName string, cfg config.Loki) (*Reloadable, error) {
+	client, err := NewLogger(sourceName, jobName, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reloadable{
+		sourceName: sourceName,
+		jobName:    jobName,
+		client:     client,
+	}, nil
+}
+
+// Reconfigure creates a client for the new agent, replaces the current one and flushes the old client.
+func (r *Reloadable) Reconfigure(cfg config.Loki) error {
+	client, err := NewLogger(r.sourceName, r.jobName, cfg)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	old := r.client
+	r.client = client
+	r.mu.Unlock()
+
+	old.Shutdown()
+
+	return nil
+}
+
+func (r *Reloadable) current() promtail.Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.client
+}
+
+func (r *Reloadable) Debugf(format string, args ...interface{}) {
+	r.current().Debugf(format, args...)
+}
+
+func (r *Reloadable) Infof(format string, args ...interface{}) {
+	r.current().Infof(format, args...)
+}
+
+func (r *Reloadable) Warnf(format string, args ...interface{}) {
+	r.current().Warnf(format, args...)
+}
+
+func (r *Reloadable) Errorf(format string, args ...interface{}) {
+	r.current().Errorf(format, args...)
+}
+
+func (r *Reloadable) Shutdown() {
+	r.current().Shutdown()
+}
@@ -6,46 +6,77 @@ import (
 	"github.com/rusrafkasimov/catalogs/internal/config"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
 )
 
 type mgoDB struct {
 	ctx    context.Context
-	Client *mongo.Client
+	logger promtail.Client
+
+	mu     sync.RWMutex
+	client *mongo.Client
 }
 
-func InitDatabase(ctx context.Context, log promtail.Client, config *config.Configuration) (*mgoDB, error) {
-	MongoHost, err := config.Get("MONGO_HOST")
-	if err != nil || MongoHost == ""{
-		log.Errorf("Error: can't parse mongo host. %s", err.Error())
+func InitDatabase(ctx context.Context, log promtail.Client, cfg config.Mongo) (*mgoDB, error) {
+	client, err := connect(ctx, log, cfg)
+	if err != nil {
 		return nil, err
 	}
 
-	MongoUsername, err := config.Get("MONGO_USERNAME")
-	if err != nil || MongoUsername == ""{
-		log.Errorf("Error: can't parse mongo username. %s", err.Error())
-		return nil, err
-	}
+	return &mgoDB{
+		ctx:    ctx,
+		logger: log,
+		client: client,
+	}, nil
+}
 
-	MongoPassword, err := config.Get("MONGO_PASSWORD")
-	if err != nil || MongoPassword == ""{
-		log.Errorf("Error: can't parse mongo pass. %s", err.Error())
-		return nil, err
-	}
+// Client returns the current mongo client.
+func (m *mgoDB) Client() *mongo.Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	MongoDatabase, err := config.Get("MONGO_DATABASE")
-	if err != nil || MongoDatabase == ""{
-		log.Errorf("Error: can't parse mongo database. %s", err.Error())
-		return nil, err
+	return m.client
+}
+
+// Reconnect connects with new settings and replaces the current client. The old client is
+// disconnected after its in-use connections are returned, so in-flight operations complete.
+func (m *mgoDB) Reconnect(cfg config.Mongo) error {
+	client, err := connect(m.ctx, m.logger, cfg)
+	if err != nil {
+		return err
 	}
 
+	m.mu.Lock()
+	old := m.client
+	m.client = client
+	m.mu.Unlock()
+
+	m.logger.Infof("Mongo client reconnected to %s", cfg.Host)
+
+	go func() {
+		if err := old.Disconnect(m.ctx); err != nil {
+			m.logger.Errorf("Error: can't disconnect old mongo client. %s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+// Disconnect closes the current client.
+func (m *mgoDB) Disconnect(ctx context.Context) error {
+	return m.Client().Disconnect(ctx)
+}
+
+// connect creates a new client and checks it with ping.
+func connect(ctx context.Context, log promtail.Client, cfg config.Mongo) (*mongo.Client, error) {
 	credential := options.Credential{
 		AuthMechanism: "SCRAM-SHA-1",
-		AuthSource:    MongoDatabase,
-		Username:      MongoUsername,
-		Password:      MongoPassword,
+		AuthSource:    cfg.Database,
+		Username:      cfg.Username,
+		Password:      cfg.Password,
 	}
 
-	clientOptions := options.Client().ApplyURI("mongodb://" + MongoHost).SetAuth(credential)
+	clientOptions := options.Client().ApplyURI("mongodb://" + cfg.Host).SetAuth(credential)
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
@@ -56,11 +87,9 @@ func InitDatabase(ctx context.Context, log promtail.Client, config *config.Confi
 	err = client.Ping(ctx, nil)
 	if err != nil {
 		log.Errorf("Error: can't ping mongo client. %s", err.Error())
+		_ = client.Disconnect(ctx)
 		return nil, err
 	}
 
-	return &mgoDB{
-		ctx:    ctx,
-		Client: client,
-	}, nil
+	return client, nil
 }
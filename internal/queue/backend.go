@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"os"
+	"regexp"
+)
+
+const (
+	BackendSTAN      = "stan"
+	BackendJetStream = "jetstream"
+	BackendMemory    = "memory"
+	BackendKafka     = "kafka"
+)
+
+// invalidDurableChars are not allowed in JetStream consumer names.
+var invalidDurableChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// New creates the event queue backend selected by cfg.Backend.
+func New(ctx context.Context, logger promtail.Client, cfg config.Queue) (EventQueue, error) {
+	var (
+		eventQueue EventQueue
+		err        error
+	)
+
+	switch cfg.Backend {
+	case "", BackendSTAN:
+		eventQueue, err = NewQueue(ctx, logger, cfg)
+	case BackendJetStream:
+		eventQueue, err = NewJetStreamQueue(ctx, logger, cfg)
+	case BackendMemory:
+		eventQueue = NewMemoryQueue()
+	case BackendKafka:
+		eventQueue, err = NewKafkaQueue(ctx, logger, cfg)
+	default:
+		return nil, fmt.Errorf("unknown event queue backend %q", cfg.Backend)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return eventQueue, nil
+}
+
+// durableName returns the consumer name of the node: durable or the host name. The name must survive
+// restarts, a new name starts a new consumer which reads the stream from the beginning.
+func durableName(durable string) (string, error) {
+	if durable != "" {
+		return durable, nil
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("EVENT_QUEUE_DURABLE is not set and the host name is unknown: %w", err)
+	}
+
+	return invalidDurableChars.ReplaceAllString(host, "_"), nil
+}
@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/nats-io/nats.go"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"sync"
+	"time"
+)
+
+// JetStreamQueue is a NATS JetStream event queue. Every node reads the stream with its own
+// durable push consumer named after cfg.Durable or the host name, so a restarted node continues
+// from the last acknowledged event.
+type JetStreamQueue struct {
+	logger  promtail.Client
+	nodeID  string
+	subject string
+	stream  string
+	durable string
+	ackWait time.Duration
+	nc      *nats.Conn
+	js      nats.JetStreamContext
+	sub     *nats.Subscription
+	output  chan Event
+	doneCh  chan struct{}
+	mu      sync.Mutex
+	closed  bool
+	// sending is held for reading by callbacks sending to output, Close takes it to close output
+	sending sync.RWMutex
+	stopped bool
+	now     func() time.Time
+}
+
+// NewJetStreamQueue connects to NATS, creates the stream if it does not exist and subscribes
+// the durable consumer. Reconnects are handled by the NATS client.
+func NewJetStreamQueue(ctx context.Context, logger promtail.Client, cfg config.Queue) (*JetStreamQueue, error) {
+	clientId := ctx.Value("Name").(string)
+
+	durable, err := durableName(cfg.Durable)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &JetStreamQueue{
+		logger:  logger,
+		nodeID:  clientId,
+		subject: cfg.Subject,
+		stream:  cfg.Stream,
+		durable: durable,
+		ackWait: time.Second,
+		output:  make(chan Event),
+		doneCh:  make(chan struct{}),
+		now:     time.Now,
+	}
+
+	nc, err := nats.Connect(cfg.URL,
+		nats.Name(clientId),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(time.Second),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Errorf("NATS connection lost: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			logger.Infof("NATS connection restored")
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect NATS: %w", err)
+	}
+
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to init JetStream: %w", err)
+	}
+
+	if err = ensureStream(js, q.stream, q.subject); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	q.nc = nc
+	q.js = js
+
+	q.sub, err = js.Subscribe(q.subject, q.handleMessage,
+		nats.Durable(q.durable),
+		nats.DeliverAll(),
+		nats.ManualAck(),
+		nats.MaxAckPending(1),
+		nats.AckWait(q.ackWait),
+	)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to subscribe to stream: %w", err)
+	}
+
+	logger.Infof("Established NATS JetStream connection")
+
+	return q, nil
+}
+
+// ensureStream creates the stream for subject if it does not exist.
+func ensureStream(js nats.JetStreamContext, stream, subject string) error {
+	_, err := js.StreamInfo(stream)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return fmt.Errorf("failed to get stream info: %w", err)
+	}
+
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     stream,
+		Subjects: []string{subject},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create stream: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe returns channel with replication events.
+func (q *JetStreamQueue) Subscribe() (<-chan Event, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, errQueueClosed
+	}
+
+	return q.output, nil
+}
+
+// Publish sends operation in replication stream and waits for the stream acknowledgement.
+func (q *JetStreamQueue) Publish(op *models.Operation) error {
+	if q.nc.IsClosed() || q.nc.IsReconnecting() {
+		return errNoConnection
+	}
+
+	op.Timestamp = q.now()
+	data, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("failed to encode data: %w", err)
+	}
+
+	_, err = q.js.Publish(q.subject, data)
+
+	return err
+}
+
+// handleMessage get and unmarshal message, send event to output chan. Messages which can't be
+// decoded are sent without operation.
+func (q *JetStreamQueue) handleMessage(msg *nats.Msg) {
+	var seq uint64
+	if meta, err := msg.Metadata(); err == nil {
+		seq = meta.Sequence.Stream
+	}
+
+	event := &event{
+		seq: seq,
+		ack: func() error {
+			return msg.Ack()
+		},
+	}
+
+	op := &models.Operation{}
+	if err := json.Unmarshal(msg.Data, op); err != nil {
+		trace.OnError(q.logger, nil, err)
+		event.raw = msg.Data
+		event.decodeErr = fmt.Errorf("failed to decode event: %w", err)
+	} else {
+		event.opn = op
+	}
+
+	q.sending.RLock()
+	defer q.sending.RUnlock()
+
+	if q.stopped {
+		return
+	}
+
+	select {
+	case q.output <- event:
+	case <-q.doneCh:
+	}
+}
+
+// Close drains the subscription and closes the connection. The durable consumer is kept on the server.
+func (q *JetStreamQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+
+	q.closed = true
+	close(q.doneCh)
+
+	var err error
+	if q.sub != nil {
+		err = q.sub.Unsubscribe()
+	}
+	q.nc.Close()
+
+	// Callbacks still sending return on doneCh, wait for them before closing output
+	q.sending.Lock()
+	q.stopped = true
+	close(q.output)
+	q.sending.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	return nil
+}
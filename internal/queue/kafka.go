@@ -0,0 +1,291 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/go-kit/kit/sd/lb"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"github.com/segmentio/kafka-go"
+	"strings"
+	"sync"
+	"time"
+)
+
+const kafkaWriteTimeout = 10 * time.Second
+
+// kafkaWriter is the part of kafka.Writer used by KafkaQueue.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// kafkaReader is the part of kafka.Reader used by KafkaQueue.
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaQueue is a Kafka-based event queue. Operations are keyed by catalog ID, so events of one
+// catalog land in one partition and keep their order. Every node reads the topic with its own
+// consumer group and commits the offset when the event is acknowledged. Like the NATS Streaming
+// queue, only one event is in flight and it is redelivered when not acknowledged in ackWait.
+// The event sequence is the offset within its partition, so events are Partitioned.
+type KafkaQueue struct {
+	logger           promtail.Client
+	writer           kafkaWriter
+	reader           kafkaReader
+	ackWait          time.Duration
+	reconnectTimeout time.Duration
+
+	mu      sync.Mutex
+	output  chan Event
+	offsets map[int]int64
+	closed  bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	now func() time.Time
+}
+
+// NewKafkaQueue creates a Kafka queue for cfg.Brokers and the cfg.Subject topic. The consumer group
+// is cfg.Durable or the host name, so a restarted node continues from the committed offsets.
+func NewKafkaQueue(ctx context.Context, logger promtail.Client, cfg config.Queue) (*KafkaQueue, error) {
+	groupID, err := durableName(cfg.Durable)
+	if err != nil {
+		return nil, err
+	}
+
+	brokers := strings.Split(cfg.Brokers, ",")
+	for i := range brokers {
+		brokers[i] = strings.TrimSpace(brokers[i])
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        cfg.Subject,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		GroupID:     groupID,
+		Topic:       cfg.Subject,
+		StartOffset: kafka.FirstOffset,
+	})
+
+	q := newKafkaQueue(logger, writer, reader)
+	logger.Infof("Kafka queue started for topic %s, consumer group %s", cfg.Subject, groupID)
+
+	return q, nil
+}
+
+// kafkaEvent is an event of one partition, its sequence is the offset in the partition.
+type kafkaEvent struct {
+	*event
+	partition int
+}
+
+// Partition returns the partition of the event.
+func (e *kafkaEvent) Partition() int {
+	return e.partition
+}
+
+// newKafkaQueue creates a queue with the given writer and reader and starts consuming.
+func newKafkaQueue(logger promtail.Client, writer kafkaWriter, reader kafkaReader) *KafkaQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := &KafkaQueue{
+		logger:           logger,
+		writer:           writer,
+		reader:           reader,
+		ackWait:          time.Second,
+		reconnectTimeout: time.Second,
+		output:           make(chan Event),
+		offsets:          make(map[int]int64),
+		ctx:              ctx,
+		cancel:           cancel,
+		now:              time.Now,
+	}
+
+	q.wg.Add(1)
+	go q.consume()
+
+	return q
+}
+
+// Subscribe returns channel with replication events.
+func (q *KafkaQueue) Subscribe() (<-chan Event, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, errQueueClosed
+	}
+
+	return q.output, nil
+}
+
+// Publish sends operation to the topic, keyed by catalog ID.
+func (q *KafkaQueue) Publish(op *models.Operation) error {
+	op.Timestamp = q.now()
+	data, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("failed to encode data: %w", err)
+	}
+
+	msg := kafka.Message{Value: data}
+	if op.Catalog != nil {
+		msg.Key = []byte(op.Catalog.ID.Hex())
+	}
+
+	ctx, cancel := context.WithTimeout(q.ctx, kafkaWriteTimeout)
+	defer cancel()
+
+	return q.writer.WriteMessages(ctx, msg)
+}
+
+// Offsets returns the last acknowledged offset of every partition.
+func (q *KafkaQueue) Offsets() map[int]int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	res := make(map[int]int64, len(q.offsets))
+	for k, v := range q.offsets {
+		res[k] = v
+	}
+
+	return res
+}
+
+// consume fetches messages one by one and delivers them to the output chan.
+func (q *KafkaQueue) consume() {
+	defer q.wg.Done()
+
+	for {
+		msg, err := q.reader.FetchMessage(q.ctx)
+		if err != nil {
+			if q.ctx.Err() != nil {
+				return
+			}
+
+			trace.OnError(q.logger, nil, err)
+			select {
+			case <-time.After(q.reconnectTimeout):
+				continue
+			case <-q.ctx.Done():
+				return
+			}
+		}
+
+		op := &models.Operation{}
+		var decodeErr error
+		if err = json.Unmarshal(msg.Value, op); err != nil {
+			trace.OnError(q.logger, nil, err)
+			op = nil
+			decodeErr = fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		if !q.deliver(msg, op, decodeErr) {
+			return
+		}
+	}
+}
+
+// deliver sends the event and waits for its acknowledgement, redelivering it after ackWait.
+// It returns false when the queue is closed.
+func (q *KafkaQueue) deliver(msg kafka.Message, op *models.Operation, decodeErr error) bool {
+	for {
+		acked := make(chan struct{})
+		var once sync.Once
+
+		event := &kafkaEvent{
+			event: &event{
+				opn: op,
+				seq: uint64(msg.Offset),
+				ack: func() error {
+					err := q.commit(msg)
+					if err == nil {
+						once.Do(func() { close(acked) })
+					}
+					return err
+				},
+			},
+			partition: msg.Partition,
+		}
+		if decodeErr != nil {
+			event.raw = msg.Value
+			event.decodeErr = decodeErr
+		}
+
+		select {
+		case q.output <- event:
+		case <-q.ctx.Done():
+			return false
+		}
+
+		select {
+		case <-acked:
+			return true
+		case <-time.After(q.ackWait):
+		case <-q.ctx.Done():
+			return false
+		}
+	}
+}
+
+// commit commits the message offset for the consumer group.
+func (q *KafkaQueue) commit(msg kafka.Message) error {
+	if err := q.reader.CommitMessages(q.ctx, msg); err != nil {
+		return fmt.Errorf("failed to commit offset: %w", err)
+	}
+
+	q.mu.Lock()
+	q.offsets[msg.Partition] = msg.Offset
+	q.mu.Unlock()
+
+	return nil
+}
+
+// Close stops consuming and closes the writer and the reader.
+func (q *KafkaQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	q.cancel()
+	q.wg.Wait()
+
+	close(q.output)
+
+	finalErr := lb.RetryError{}
+
+	if err := q.writer.Close(); err != nil {
+		finalErr.RawErrors = append(finalErr.RawErrors, fmt.Errorf("failed to close writer: %w", err))
+	}
+
+	if err := q.reader.Close(); err != nil {
+		finalErr.RawErrors = append(finalErr.RawErrors, fmt.Errorf("failed to close reader: %w", err))
+	}
+
+	if len(finalErr.RawErrors) > 0 {
+		err := errors.New("unable to close queue")
+		finalErr.RawErrors = append(finalErr.RawErrors, err)
+		finalErr.Final = err
+		return finalErr
+	}
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package queue
+
+import (
+	"context"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"hash/fnv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memBroker is an in-memory stand-in of a Kafka topic. Messages are partitioned by key hash and
+// consumer groups keep the last committed offset of every partition.
+type memBroker struct {
+	mu         sync.Mutex
+	partitions int
+	log        []kafka.Message
+	next       map[int]int64
+	committed  map[string]map[int]int64
+	appended   chan struct{}
+}
+
+func newMemBroker(partitions int) *memBroker {
+	return &memBroker{
+		partitions: partitions,
+		next:       make(map[int]int64),
+		committed:  make(map[string]map[int]int64),
+		appended:   make(chan struct{}),
+	}
+}
+
+// writer returns a producer of the topic.
+func (b *memBroker) writer() kafkaWriter {
+	return &memWriter{broker: b}
+}
+
+// reader returns a consumer of the topic in the group, it starts after the committed offsets.
+func (b *memBroker) reader(group string) kafkaReader {
+	return &memReader{broker: b, group: group}
+}
+
+func (b *memBroker) append(msgs []kafka.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, msg := range msgs {
+		h := fnv.New32a()
+		_, _ = h.Write(msg.Key)
+		msg.Partition = int(h.Sum32() % uint32(b.partitions))
+		msg.Offset = b.next[msg.Partition]
+		b.next[msg.Partition]++
+		b.log = append(b.log, msg)
+	}
+
+	close(b.appended)
+	b.appended = make(chan struct{})
+}
+
+func (b *memBroker) commit(group string, msgs []kafka.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.committed[group] == nil {
+		b.committed[group] = make(map[int]int64)
+	}
+	for _, msg := range msgs {
+		b.committed[group][msg.Partition] = msg.Offset + 1
+	}
+}
+
+type memWriter struct {
+	broker *memBroker
+}
+
+func (w *memWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.broker.append(msgs)
+	return nil
+}
+
+func (w *memWriter) Close() error {
+	return nil
+}
+
+type memReader struct {
+	broker *memBroker
+	group  string
+	pos    int
+}
+
+func (r *memReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		r.broker.mu.Lock()
+		for r.pos < len(r.broker.log) {
+			msg := r.broker.log[r.pos]
+			r.pos++
+			if msg.Offset >= r.broker.committed[r.group][msg.Partition] {
+				r.broker.mu.Unlock()
+				return msg, nil
+			}
+		}
+		appended := r.broker.appended
+		r.broker.mu.Unlock()
+
+		select {
+		case <-appended:
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		}
+	}
+}
+
+func (r *memReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.broker.commit(r.group, msgs)
+	return nil
+}
+
+func (r *memReader) Close() error {
+	return nil
+}
+
+// nopLogger discards log messages.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Shutdown()                     {}
+
+func upsert(id primitive.ObjectID, version int64) *models.Operation {
+	return &models.Operation{
+		Type:    models.OperationTypeCatalogs,
+		Method:  models.OperationMethodUpsert,
+		Catalog: &models.Catalog{ID: id, Version: version},
+	}
+}
+
+// receive waits for the next event of the queue.
+func receive(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("event channel closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event delivered")
+	}
+
+	return nil
+}
+
+func TestKafkaQueueKeepsOrderOfCatalog(t *testing.T) {
+	broker := newMemBroker(3)
+	q := newKafkaQueue(nopLogger{}, broker.writer(), broker.reader("node"))
+	defer q.Close()
+
+	ids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+	for version := int64(1); version <= 3; version++ {
+		for _, id := range ids {
+			if err := q.Publish(upsert(id, version)); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+		}
+	}
+
+	events, err := q.Subscribe()
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	versions := make(map[primitive.ObjectID]int64)
+	for i := 0; i < len(ids)*3; i++ {
+		event := receive(t, events)
+		catalog := event.Operation().Catalog
+		if catalog.Version != versions[catalog.ID]+1 {
+			t.Fatalf("catalog %s: version %d after %d", catalog.ID.Hex(), catalog.Version, versions[catalog.ID])
+		}
+		versions[catalog.ID] = catalog.Version
+
+		if err := event.Ack(); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+	}
+
+	// Every message of a catalog is keyed by its ID and lands in one partition
+	partitions := make(map[string]int)
+	for _, msg := range broker.log {
+		key := string(msg.Key)
+		if p, ok := partitions[key]; ok && p != msg.Partition {
+			t.Fatalf("catalog %s is in partitions %d and %d", key, p, msg.Partition)
+		}
+		partitions[key] = msg.Partition
+	}
+	if len(partitions) != len(ids) {
+		t.Fatalf("got %d keys, want %d", len(partitions), len(ids))
+	}
+
+	offsets := q.Offsets()
+	for partition, next := range broker.next {
+		if offsets[partition] != next-1 {
+			t.Fatalf("partition %d: acknowledged offset %d, want %d", partition, offsets[partition], next-1)
+		}
+	}
+}
+
+func TestKafkaQueueRedeliversUnacknowledged(t *testing.T) {
+	broker := newMemBroker(1)
+	q := newKafkaQueue(nopLogger{}, broker.writer(), broker.reader("node"))
+	q.ackWait = 20 * time.Millisecond
+	defer q.Close()
+
+	id := primitive.NewObjectID()
+	if err := q.Publish(upsert(id, 1)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := q.Publish(upsert(id, 2)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	events, _ := q.Subscribe()
+
+	first := receive(t, events)
+	again := receive(t, events)
+	if again.Sequence() != first.Sequence() {
+		t.Fatalf("got sequence %d, want redelivery of %d", again.Sequence(), first.Sequence())
+	}
+	if err := again.Ack(); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	if next := receive(t, events); next.Operation().Catalog.Version != 2 {
+		t.Fatalf("got version %d after acknowledgement, want 2", next.Operation().Catalog.Version)
+	}
+}
+
+func TestKafkaQueueResumesFromCommittedOffset(t *testing.T) {
+	broker := newMemBroker(1)
+	id := primitive.NewObjectID()
+
+	q := newKafkaQueue(nopLogger{}, broker.writer(), broker.reader("node"))
+	for version := int64(1); version <= 3; version++ {
+		if err := q.Publish(upsert(id, version)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	events, _ := q.Subscribe()
+	if err := receive(t, events).Ack(); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, ok := <-events; ok {
+		t.Fatal("event channel is open after Close")
+	}
+
+	// A restarted node of the same group continues after the acknowledged event
+	restarted := newKafkaQueue(nopLogger{}, broker.writer(), broker.reader("node"))
+	defer restarted.Close()
+
+	events, _ = restarted.Subscribe()
+	if version := receive(t, events).Operation().Catalog.Version; version != 2 {
+		t.Fatalf("restarted node got version %d, want 2", version)
+	}
+
+	// Another group reads the topic from the beginning
+	other := newKafkaQueue(nopLogger{}, broker.writer(), broker.reader("other"))
+	defer other.Close()
+
+	events, _ = other.Subscribe()
+	if version := receive(t, events).Operation().Catalog.Version; version != 1 {
+		t.Fatalf("new group got version %d, want 1", version)
+	}
+}
+
+func TestKafkaQueueEventsArePartitioned(t *testing.T) {
+	broker := newMemBroker(2)
+	q := newKafkaQueue(nopLogger{}, broker.writer(), broker.reader("node"))
+	defer q.Close()
+
+	// Publish until both partitions have a message at offset zero
+	for len(broker.next) < 2 {
+		if err := q.Publish(upsert(primitive.NewObjectID(), 1)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	events, _ := q.Subscribe()
+	first := make(map[int]uint64)
+	for len(first) < 2 {
+		event := receive(t, events)
+		partitioned, ok := event.(Partitioned)
+		if !ok {
+			t.Fatalf("event %T has no partition", event)
+		}
+		if _, ok := first[partitioned.Partition()]; !ok {
+			first[partitioned.Partition()] = event.Sequence()
+		}
+		if err := event.Ack(); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+	}
+
+	// Sequences are offsets and repeat across partitions
+	if first[0] != 0 || first[1] != 0 {
+		t.Fatalf("first sequences of partitions = %v, want 0 in both", first)
+	}
+}
+
+func TestKafkaQueueDeliversUndecodable(t *testing.T) {
+	broker := newMemBroker(1)
+	broker.append([]kafka.Message{{Key: []byte("broken"), Value: []byte("{")}})
+
+	q := newKafkaQueue(nopLogger{}, broker.writer(), broker.reader("node"))
+	defer q.Close()
+
+	events, _ := q.Subscribe()
+	event := receive(t, events)
+	if event.Operation() != nil {
+		t.Fatal("undecodable event has an operation")
+	}
+
+	undecodable, ok := event.(Undecodable)
+	if !ok || undecodable.DecodeError() == nil {
+		t.Fatal("event does not report the decode error")
+	}
+	if string(undecodable.Payload()) != "{" {
+		t.Fatalf("payload = %q, want %q", undecodable.Payload(), "{")
+	}
+}
+
+func TestDurableName(t *testing.T) {
+	if name, err := durableName("catalogs-1"); err != nil || name != "catalogs-1" {
+		t.Fatalf("durableName(catalogs-1) = %q, %v", name, err)
+	}
+
+	first, err := durableName("")
+	if err != nil {
+		t.Fatalf("durableName: %v", err)
+	}
+	second, _ := durableName("")
+	if first == "" || first != second {
+		t.Fatalf("durableName is not stable: %q, %q", first, second)
+	}
+	if invalidDurableChars.MatchString(first) {
+		t.Fatalf("durableName %q has invalid characters", first)
+	}
+}
@@ -0,0 +1,180 @@
+package queue
+
+import (
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"sync"
+	"time"
+)
+
+const memoryQueueBuffer = 1024
+
+// MemoryQueue is an in-process event bus for single node deployments and tests. Every subscriber
+// receives every published operation in publish order. Like other queues, only one event of a
+// subscriber is in flight and it is redelivered when not acknowledged in ackWait.
+type MemoryQueue struct {
+	mu          sync.Mutex
+	publishMu   sync.Mutex
+	subscribers []*memorySubscriber
+	sequence    uint64
+	closed      bool
+	doneCh      chan struct{}
+	wg          sync.WaitGroup
+	ackWait     time.Duration
+
+	now func() time.Time
+}
+
+// memorySubscriber keeps published events of one subscriber until they are delivered.
+type memorySubscriber struct {
+	pending chan *event
+	output  chan Event
+}
+
+// NewMemoryQueue creates a new MemoryQueue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		doneCh:  make(chan struct{}),
+		ackWait: time.Second,
+		now:     time.Now,
+	}
+}
+
+// Subscribe returns a new channel with replication events published after the call.
+func (q *MemoryQueue) Subscribe() (<-chan Event, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, errQueueClosed
+	}
+
+	sub := &memorySubscriber{
+		pending: make(chan *event, memoryQueueBuffer),
+		output:  make(chan Event),
+	}
+	q.subscribers = append(q.subscribers, sub)
+
+	q.wg.Add(1)
+	go q.consume(sub)
+
+	return sub.output, nil
+}
+
+// Publish delivers a copy of operation to every subscriber. It blocks while a subscriber buffer is full.
+func (q *MemoryQueue) Publish(op *models.Operation) error {
+	q.publishMu.Lock()
+	defer q.publishMu.Unlock()
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return errQueueClosed
+	}
+
+	op.Timestamp = q.now()
+	q.sequence++
+	seq := q.sequence
+	subscribers := append([]*memorySubscriber(nil), q.subscribers...)
+	q.mu.Unlock()
+
+	for _, sub := range subscribers {
+		event := &event{
+			opn: copyOperation(op),
+			seq: seq,
+		}
+
+		select {
+		case sub.pending <- event:
+		case <-q.doneCh:
+			return errQueueClosed
+		}
+	}
+
+	return nil
+}
+
+// consume delivers pending events of the subscriber one by one until the queue is closed.
+func (q *MemoryQueue) consume(sub *memorySubscriber) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case evt := <-sub.pending:
+			if !q.deliver(sub.output, evt) {
+				return
+			}
+		case <-q.doneCh:
+			return
+		}
+	}
+}
+
+// deliver sends the event and waits for its acknowledgement, redelivering it after ackWait.
+// It returns false when the queue is closed.
+func (q *MemoryQueue) deliver(output chan Event, evt *event) bool {
+	for {
+		acked := make(chan struct{})
+		var once sync.Once
+
+		delivery := &event{
+			opn: evt.opn,
+			seq: evt.seq,
+			ack: func() error {
+				once.Do(func() { close(acked) })
+				return nil
+			},
+		}
+
+		select {
+		case output <- delivery:
+		case <-q.doneCh:
+			return false
+		}
+
+		select {
+		case <-acked:
+			return true
+		case <-time.After(q.ackWait):
+		case <-q.doneCh:
+			return false
+		}
+	}
+}
+
+// Close closes subscriber channels. Blocked publishers are released with errQueueClosed.
+func (q *MemoryQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+
+	q.closed = true
+	close(q.doneCh)
+	q.mu.Unlock()
+
+	// Wait for the running Publish and deliveries before closing channels they may write to.
+	q.publishMu.Lock()
+	defer q.publishMu.Unlock()
+	q.wg.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, sub := range q.subscribers {
+		close(sub.output)
+	}
+
+	return nil
+}
+
+// copyOperation copies operation and catalog, so subscribers do not share data with the publisher.
+func copyOperation(op *models.Operation) *models.Operation {
+	res := *op
+	if op.Catalog != nil {
+		catalog := *op.Catalog
+		res.Catalog = &catalog
+	}
+
+	return &res
+}
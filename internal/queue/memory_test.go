@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestMemoryQueueDeliversToEverySubscriber(t *testing.T) {
+	q := NewMemoryQueue()
+	defer q.Close()
+
+	first, _ := q.Subscribe()
+	second, _ := q.Subscribe()
+
+	id := primitive.NewObjectID()
+	for version := int64(1); version <= 3; version++ {
+		if err := q.Publish(upsert(id, version)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	for _, events := range []<-chan Event{first, second} {
+		for version := int64(1); version <= 3; version++ {
+			event := receive(t, events)
+			if event.Operation().Catalog.Version != version || event.Sequence() != uint64(version) {
+				t.Fatalf("got version %d, sequence %d, want %d", event.Operation().Catalog.Version, event.Sequence(), version)
+			}
+			if err := event.Ack(); err != nil {
+				t.Fatalf("Ack: %v", err)
+			}
+		}
+	}
+}
+
+func TestMemoryQueueRedeliversUnacknowledged(t *testing.T) {
+	q := NewMemoryQueue()
+	q.ackWait = 20 * time.Millisecond
+	defer q.Close()
+
+	events, _ := q.Subscribe()
+
+	id := primitive.NewObjectID()
+	if err := q.Publish(upsert(id, 1)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := q.Publish(upsert(id, 2)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	first := receive(t, events)
+	again := receive(t, events)
+	if again.Sequence() != first.Sequence() || again.Operation().Catalog.Version != 1 {
+		t.Fatalf("got sequence %d, want redelivery of %d", again.Sequence(), first.Sequence())
+	}
+	if err := again.Ack(); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	if next := receive(t, events); next.Operation().Catalog.Version != 2 {
+		t.Fatalf("got version %d after acknowledgement, want 2", next.Operation().Catalog.Version)
+	}
+}
+
+func TestMemoryQueueClose(t *testing.T) {
+	q := NewMemoryQueue()
+	events, _ := q.Subscribe()
+
+	if err := q.Publish(upsert(primitive.NewObjectID(), 1)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Close does not wait for the event in flight to be acknowledged
+	receive(t, events)
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, ok := <-events; ok {
+		t.Fatal("event channel is open after Close")
+	}
+
+	if err := q.Publish(upsert(primitive.NewObjectID(), 1)); err != errQueueClosed {
+		t.Fatalf("Publish after Close: %v, want errQueueClosed", err)
+	}
+	if _, err := q.Subscribe(); err != errQueueClosed {
+		t.Fatalf("Subscribe after Close: %v, want errQueueClosed", err)
+	}
+}
@@ -26,10 +26,26 @@ type Event interface {
 	Ack() error
 }
 
+// Undecodable is implemented by events which may carry a payload that failed to decode.
+// Such events have no operation and are delivered so the consumer can dead-letter them.
+type Undecodable interface {
+	DecodeError() error
+	Payload() []byte
+}
+
+// Partitioned is implemented by events of sources with several partitions. Their sequence is only
+// unique within the partition.
+type Partitioned interface {
+	Partition() int
+}
+
 type event struct {
 	opn *models.Operation
 	seq uint64
 	ack func() error
+
+	raw       []byte
+	decodeErr error
 }
 
 
@@ -45,12 +61,21 @@ func (e *event) Ack() error {
 	return e.ack()
 }
 
+func (e *event) DecodeError() error {
+	return e.decodeErr
+}
+
+func (e *event) Payload() []byte {
+	return e.raw
+}
+
 
 // Queue is a NATS-based event queue. It allows subscribing to events or publish them.
 
 type EventQueue interface {
 	Publish(op *models.Operation) error
 	Subscribe() (<-chan Event, error)
+	Close() error
 }
 
 type Queue struct {
@@ -78,32 +103,17 @@ type Queue struct {
 
 
 // NewQueue creates a new Queue.
-func NewQueue(ctx context.Context, logger promtail.Client, cfg *config.Configuration) (*Queue, error) {
-	URL, err := cfg.Get("EVENT_QUEUE_URL")
-	if err != nil {
-		fmt.Println(err)
-	}
-
-	ClusterID, err := cfg.Get("EVENT_QUEUE_CLUSTER_ID")
-	if err != nil {
-		fmt.Println(err)
-	}
-
-	Subject, err := cfg.Get("EVENT_QUEUE_SUBJECT")
-	if err != nil {
-		fmt.Println(err)
-	}
-
+func NewQueue(ctx context.Context, logger promtail.Client, cfg config.Queue) (*Queue, error) {
 	clientId := ctx.Value("Name")
 	q := &Queue{
 		writeOnly:        false,
 		logger:           logger,
 		nodeID:           clientId.(string),
-		url:              URL,
+		url:              cfg.URL,
 		ackWait:          time.Second,
 		reconnectTimeout: time.Second,
-		clusterID:        ClusterID,
-		subject:          Subject,
+		clusterID:        cfg.ClusterID,
+		subject:          cfg.Subject,
 		mu:               sync.RWMutex{},
 		conn:             nil,
 		input:            make(chan Event),
@@ -115,7 +125,7 @@ func NewQueue(ctx context.Context, logger promtail.Client, cfg *config.Configura
 		now:              time.Now,
 	}
 
-	err = q.connect()
+	err := q.connect()
 	if err != nil {
 		trace.OnError(logger, nil, err)
 		q.dialBackground()
@@ -134,6 +144,10 @@ func (q *Queue) connect() error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	if q.conn != nil {
+		return nil
+	}
+
 	stanOpts := []stan.Option{
 		stan.SetConnectionLostHandler(q.recoverConn),
 		stan.NatsURL(q.url),
@@ -183,9 +197,73 @@ func (q *Queue) getConn() (stan.Conn, error) {
 	return q.conn, nil
 }
 
+// Reconfigure closes the current connection and dials with new settings. The subscription
+// continues from the last sequence if the cluster and subject are unchanged.
+func (q *Queue) Reconfigure(cfg config.Queue) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return errQueueClosed
+	}
+
+	if q.clusterID != cfg.ClusterID || q.subject != cfg.Subject {
+		q.sequenceNumber = 0
+	}
+
+	q.url = cfg.URL
+	q.clusterID = cfg.ClusterID
+	q.subject = cfg.Subject
+
+	q.redial()
+
+	return nil
+}
+
+// ResumeFrom resubscribes starting at the given sequence. It is used when memory storage is
+// restored from a snapshot taken at this sequence.
+func (q *Queue) ResumeFrom(seq uint64) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return errQueueClosed
+	}
+
+	q.sequenceNumber = seq
+
+	q.redial()
+
+	return nil
+}
+
+// redial replaces the current connection with a new one. It must be called with q.mu locked
+// and unlocks it.
+func (q *Queue) redial() {
+	old := q.conn
+	q.conn = nil
+	q.mu.Unlock()
+
+	if old != nil {
+		if err := old.Close(); err != nil {
+			trace.OnError(q.logger, nil, err)
+		}
+	}
+
+	if err := q.connect(); err != nil {
+		trace.OnError(q.logger, nil, err)
+		q.dialBackground()
+	}
+}
+
 // recoverConn notifies about disconnection and start dialBackground.
-func (q *Queue) recoverConn(_ stan.Conn, reason error) {
-	q.logger.Errorf("NATS connection lost: ", reason)
+func (q *Queue) recoverConn(conn stan.Conn, reason error) {
+	q.logger.Errorf("NATS connection lost: %v", reason)
+
+	q.mu.Lock()
+	if q.conn == conn {
+		q.conn = nil
+	}
+	q.mu.Unlock()
+
 	q.dialBackground()
 }
 
@@ -246,25 +324,27 @@ func (q *Queue) Publish(op *models.Operation) error {
 }
 
 
-// handleMessage get and unmarshal message, send event to input chan.
+// handleMessage get and unmarshal message, send event to input chan. Messages which can't be
+// decoded are sent without operation.
 func (q *Queue) handleMessage(msg *stan.Msg) {
+	event := &event{
+		seq: msg.Sequence,
+		ack: msg.Ack,
+	}
+
 	op := &models.Operation{}
-	err := json.Unmarshal(msg.Data, op)
-	if err != nil {
+	if err := json.Unmarshal(msg.Data, op); err != nil {
 		trace.OnError(q.logger, nil, err)
-		return
+		event.raw = msg.Data
+		event.decodeErr = fmt.Errorf("failed to decode event: %w", err)
+	} else {
+		event.opn = op
 	}
 
 	q.mu.Lock()
 	q.sequenceNumber = msg.Sequence
 	q.mu.Unlock()
 
-	event := &event{
-		opn: op,
-		seq: msg.Sequence,
-		ack: msg.Ack,
-	}
-
 	select {
 	case q.input <- event:
 	case <-q.doneCh:
@@ -297,15 +377,22 @@ func (q *Queue) handleEvents() {
 // Close closes the event queue.
 func (q *Queue) Close() error {
 	q.mu.Lock()
-	defer q.mu.Unlock()
-
 	if q.closed {
+		q.mu.Unlock()
 		return nil
 	}
 
+	q.closed = true
 	close(q.doneCh)
+	q.mu.Unlock()
+
+	// Background goroutines may wait for the mutex while reconnecting,
+	// so they must be stopped before it is taken again.
 	q.wg.Wait()
 
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	close(q.output)
 
 	finalErr := lb.RetryError{}
@@ -318,8 +405,6 @@ func (q *Queue) Close() error {
 		}
 	}
 
-	q.closed = true
-
 	if len(finalErr.RawErrors) > 0 {
 		err := errors.New("unable to close queue")
 		finalErr.RawErrors = append(finalErr.RawErrors, err)
@@ -0,0 +1,120 @@
+package replicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/rusrafkasimov/catalogs/internal/queue"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"sync/atomic"
+	"time"
+)
+
+// DeadLetterSink stores events which can not be applied.
+type DeadLetterSink interface {
+	AddDeadLetter(ctx context.Context, deadLetter *models.DeadLetter) error
+}
+
+// DeadLettered returns the number of events stored as dead letters since start.
+func (r *replicator) DeadLettered() uint64 {
+	return atomic.LoadUint64(&r.deadLettered)
+}
+
+// eventKey identifies an event of the source, sequences of partitioned sources repeat across partitions.
+type eventKey struct {
+	partition int
+	sequence  uint64
+}
+
+// keyOf returns the key of the event, events of sources without partitions are in partition -1.
+func keyOf(evt queue.Event) eventKey {
+	key := eventKey{partition: -1, sequence: evt.Sequence()}
+	if partitioned, ok := evt.(queue.Partitioned); ok {
+		key.partition = partitioned.Partition()
+	}
+
+	return key
+}
+
+// String formats the key for logs.
+func (k eventKey) String() string {
+	if k.partition < 0 {
+		return fmt.Sprintf("%d", k.sequence)
+	}
+
+	return fmt.Sprintf("%d in partition %d", k.sequence, k.partition)
+}
+
+// handleEvent applies the event and acknowledges it. An event which fails to apply is left
+// unacknowledged to be redelivered until MaxRedeliveries, then it is stored as a dead letter and
+// acknowledged. Events which can not be decoded are dead-lettered at once.
+func (r *replicator) handleEvent(ctx context.Context, evt queue.Event) error {
+	var cause error
+	if undecodable, ok := evt.(queue.Undecodable); ok && undecodable.DecodeError() != nil {
+		cause = undecodable.DecodeError()
+	} else {
+		cause = r.processOperation(evt.Operation(), evt.Sequence())
+	}
+
+	if cause == nil {
+		r.failedAttempts = 0
+		return evt.Ack()
+	}
+
+	key := keyOf(evt)
+	if r.failedAttempts == 0 || r.failedEvent != key {
+		r.failedEvent = key
+		r.failedAttempts = 0
+	}
+	r.failedAttempts++
+
+	undecodable := evt.Operation() == nil
+	if !undecodable && r.failedAttempts < r.cfg.MaxRedeliveries {
+		return fmt.Errorf("failed to apply event %s, attempt %d: %w", key, r.failedAttempts, cause)
+	}
+
+	if err := r.deadLetter(ctx, evt, cause); err != nil {
+		return err
+	}
+	r.failedAttempts = 0
+
+	if err := evt.Ack(); err != nil {
+		return err
+	}
+
+	r.logger.Warnf("Replicator: event %s moved to dead letters. %s", key, cause.Error())
+
+	return nil
+}
+
+// deadLetter stores the event with its error.
+func (r *replicator) deadLetter(ctx context.Context, evt queue.Event, cause error) error {
+	if r.deadLetters == nil {
+		return fmt.Errorf("no dead letter storage for event %s: %w", keyOf(evt), cause)
+	}
+
+	deadLetter := &models.DeadLetter{
+		Node:      r.node,
+		Sequence:  evt.Sequence(),
+		Operation: evt.Operation(),
+		Attempts:  r.failedAttempts,
+		Error:     cause.Error(),
+		CreatedAt: time.Now(),
+	}
+
+	if partitioned, ok := evt.(queue.Partitioned); ok {
+		partition := partitioned.Partition()
+		deadLetter.Partition = &partition
+	}
+
+	if undecodable, ok := evt.(queue.Undecodable); ok {
+		deadLetter.Payload = string(undecodable.Payload())
+	}
+
+	if err := r.deadLetters.AddDeadLetter(ctx, deadLetter); err != nil {
+		return err
+	}
+
+	atomic.AddUint64(&r.deadLettered, 1)
+
+	return nil
+}
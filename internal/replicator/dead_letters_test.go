@@ -0,0 +1,123 @@
+package replicator
+
+import (
+	"context"
+	"errors"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"testing"
+)
+
+// undecodableEvent is an event whose payload failed to decode.
+type undecodableEvent struct {
+	*testEvent
+	payload string
+}
+
+func (e *undecodableEvent) DecodeError() error {
+	return errors.New("unexpected end of JSON input")
+}
+
+func (e *undecodableEvent) Payload() []byte {
+	return []byte(e.payload)
+}
+
+// partitionedEvent is an event of a partitioned source, its sequence is the offset in the partition.
+type partitionedEvent struct {
+	*testEvent
+	partition int
+}
+
+func (e *partitionedEvent) Partition() int {
+	return e.partition
+}
+
+func TestHandleEventDeadLettersAfterRedeliveries(t *testing.T) {
+	deadLetters := &fakeDeadLetters{}
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, deadLetters, config.Replication{MaxRedeliveries: 3})
+	ctx := context.Background()
+
+	failing := &testEvent{op: operation("rename", newCatalog("colors", "red", 1), 1), seq: 5}
+
+	// The event is left unacknowledged to be redelivered
+	for attempt := 1; attempt < 3; attempt++ {
+		if err := r.handleEvent(ctx, failing); err == nil {
+			t.Fatalf("attempt %d: handleEvent succeeded", attempt)
+		}
+		if failing.acks != 0 || len(deadLetters.letters) != 0 {
+			t.Fatalf("attempt %d: event acknowledged %d times, %d dead letters", attempt, failing.acks, len(deadLetters.letters))
+		}
+	}
+
+	if err := r.handleEvent(ctx, failing); err != nil {
+		t.Fatalf("last attempt: handleEvent: %v", err)
+	}
+	if failing.acks != 1 || len(deadLetters.letters) != 1 || r.DeadLettered() != 1 {
+		t.Fatalf("event acknowledged %d times, %d dead letters, want one", failing.acks, len(deadLetters.letters))
+	}
+
+	letter := deadLetters.letters[0]
+	if letter.Sequence != 5 || letter.Attempts != 3 || letter.Node != "node" || letter.Operation != failing.op || letter.Partition != nil {
+		t.Fatalf("dead letter = %+v", letter)
+	}
+
+	// Attempts of the next event are counted from one
+	next := &testEvent{op: operation("rename", newCatalog("colors", "green", 1), 1), seq: 6}
+	if err := r.handleEvent(ctx, next); err == nil || next.acks != 0 {
+		t.Fatalf("next event was dead-lettered at once: %v", err)
+	}
+
+	applied := &testEvent{op: operation(OperationMethodUpsert, newCatalog("colors", "blue", 1), 1), seq: 7}
+	if err := r.handleEvent(ctx, applied); err != nil || applied.acks != 1 {
+		t.Fatalf("applied event: %v, acknowledged %d times", err, applied.acks)
+	}
+}
+
+func TestHandleEventDeadLettersUndecodableAtOnce(t *testing.T) {
+	deadLetters := &fakeDeadLetters{}
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, deadLetters, config.Replication{MaxRedeliveries: 3})
+
+	event := &undecodableEvent{testEvent: &testEvent{seq: 9}, payload: "{"}
+	if err := r.handleEvent(context.Background(), event); err != nil {
+		t.Fatalf("handleEvent: %v", err)
+	}
+	if event.acks != 1 || len(deadLetters.letters) != 1 {
+		t.Fatalf("event acknowledged %d times, %d dead letters", event.acks, len(deadLetters.letters))
+	}
+	if letter := deadLetters.letters[0]; letter.Payload != "{" || letter.Operation != nil || letter.Attempts != 1 {
+		t.Fatalf("dead letter = %+v", letter)
+	}
+}
+
+func TestHandleEventCountsRedeliveriesPerPartition(t *testing.T) {
+	deadLetters := &fakeDeadLetters{}
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, deadLetters, config.Replication{MaxRedeliveries: 2})
+	ctx := context.Background()
+
+	// Events of two partitions at the same offset are different events
+	first := &partitionedEvent{testEvent: &testEvent{op: operation("rename", newCatalog("colors", "red", 1), 1), seq: 3}, partition: 0}
+	second := &partitionedEvent{testEvent: &testEvent{op: operation("rename", newCatalog("colors", "green", 1), 1), seq: 3}, partition: 1}
+
+	if err := r.handleEvent(ctx, first); err == nil {
+		t.Fatal("handleEvent of the first event succeeded")
+	}
+	if err := r.handleEvent(ctx, second); err == nil || len(deadLetters.letters) != 0 {
+		t.Fatalf("second event was dead-lettered on its first attempt: %v", err)
+	}
+
+	if err := r.handleEvent(ctx, second); err != nil {
+		t.Fatalf("handleEvent: %v", err)
+	}
+	letter := deadLetters.letters[0]
+	if letter.Partition == nil || *letter.Partition != 1 || letter.Sequence != 3 || letter.Attempts != 2 {
+		t.Fatalf("dead letter = %+v", letter)
+	}
+}
+
+func TestHandleEventWithoutDeadLetterStorage(t *testing.T) {
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, nil, config.Replication{MaxRedeliveries: 1})
+
+	event := &testEvent{op: operation("rename", newCatalog("colors", "red", 1), 1), seq: 1}
+	if err := r.handleEvent(context.Background(), event); err == nil || event.acks != 0 {
+		t.Fatalf("event without dead letter storage: %v, acknowledged %d times", err, event.acks)
+	}
+}
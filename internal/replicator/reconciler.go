@@ -0,0 +1,252 @@
+package replicator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+var errNotReady = errors.New("replicator is not ready")
+
+// CategoryDrift describes the difference of one category between memory storage and Mongo.
+type CategoryDrift struct {
+	Category string
+	// Missing catalogs are in Mongo only, Extra are in memory storage only.
+	Missing int
+	Extra   int
+	Changed int
+}
+
+// ReconcileReport is the result of one reconciliation.
+type ReconcileReport struct {
+	StartedAt  time.Time
+	Duration   time.Duration
+	Categories int
+	Drifted    []CategoryDrift
+}
+
+// Reconcile compares versions and update times of every catalog in Mongo with memory storage per category.
+// Categories which differ are read from Mongo, compared catalog by catalog and reloaded.
+func (r *replicator) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	if !r.Ready() {
+		return nil, errNotReady
+	}
+
+	r.reconcileMu.Lock()
+	defer r.reconcileMu.Unlock()
+
+	tracer := opentracing.GlobalTracer()
+	replicatorSpan := tracer.StartSpan("Replicator:Reconcile")
+	defer replicatorSpan.Finish()
+
+	report := &ReconcileReport{StartedAt: time.Now()}
+
+	stored, err := r.repo.CatalogStamps(ctx, replicatorSpan)
+	if err != nil {
+		trace.OnError(r.logger, replicatorSpan, err)
+		return nil, fmt.Errorf("failed to load stamps: %w", err)
+	}
+
+	categories := make(map[string]bool, len(stored))
+	for category := range stored {
+		categories[category] = true
+	}
+	for _, category := range r.memStore.GetCategories() {
+		categories[category] = true
+	}
+	report.Categories = len(categories)
+
+	for category := range categories {
+		if stored[category].Equal(repository.NewCategoryStamps(r.inMemory(category))) {
+			continue
+		}
+
+		drift, err := r.reload(ctx, category, replicatorSpan)
+		if err != nil {
+			trace.OnError(r.logger, replicatorSpan, err)
+			return nil, fmt.Errorf("failed to reload category %q: %w", category, err)
+		}
+		if drift != nil {
+			report.Drifted = append(report.Drifted, *drift)
+		}
+	}
+
+	sort.Slice(report.Drifted, func(i, j int) bool {
+		return report.Drifted[i].Category < report.Drifted[j].Category
+	})
+
+	report.Duration = time.Since(report.StartedAt)
+	replicatorSpan.SetTag("Drifted categories", len(report.Drifted))
+
+	atomic.AddUint64(&r.drifted, uint64(len(report.Drifted)))
+	r.reportMu.Lock()
+	r.lastReport = report
+	r.reportMu.Unlock()
+
+	if len(report.Drifted) > 0 {
+		r.logger.Warnf("Reconciler: %d of %d categories drifted and were reloaded", len(report.Drifted), report.Categories)
+	}
+
+	return report, nil
+}
+
+// LastReconcile returns the report of the last reconciliation or nil.
+func (r *replicator) LastReconcile() *ReconcileReport {
+	r.reportMu.Lock()
+	defer r.reportMu.Unlock()
+
+	return r.lastReport
+}
+
+// DriftedCategories returns the number of categories reloaded by the reconciler since start.
+func (r *replicator) DriftedCategories() uint64 {
+	return atomic.LoadUint64(&r.drifted)
+}
+
+// reconcile runs Reconcile every interval until ctx is done.
+func (r *replicator) reconcile(ctx context.Context, interval time.Duration) {
+	tc := time.NewTicker(interval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			if _, err := r.Reconcile(ctx); err != nil && ctx.Err() == nil {
+				trace.OnError(r.logger, nil, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reload reads the category from Mongo and merges it into memory storage. Replication waits until
+// the merge is done, so events of changes committed after the read are applied after it and ordered
+// by version as usual. Catalogs missing in Mongo are removed, catalogs of a newer version than read
+// are kept. It returns nil when the category doesn't differ anymore.
+func (r *replicator) reload(ctx context.Context, category string, span opentracing.Span) (*CategoryDrift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	catalogs, err := r.repo.FindCatalogsByCategory(ctx, category, span)
+	if err != nil {
+		return nil, err
+	}
+
+	actual := r.inMemory(category)
+	drift := diff(category, catalogs, actual)
+	if drift.Missing == 0 && drift.Extra == 0 && drift.Changed == 0 {
+		return nil, nil
+	}
+
+	stored := make(map[string]bool, len(catalogs))
+	for _, catalog := range catalogs {
+		id := catalog.ID.Hex()
+		stored[id] = true
+
+		// Replication applied a newer version than Mongo was read with
+		state, ok := r.catalogs[id]
+		if ok && state.version > catalog.Version {
+			continue
+		}
+
+		if err = r.apply(&models.Operation{Type: OperationTypeCatalogs, Method: OperationMethodUpsert, Catalog: catalog}); err != nil {
+			return nil, err
+		}
+		state.version = catalog.Version
+		r.catalogs[id] = state
+	}
+
+	for _, catalog := range actual {
+		if stored[catalog.ID.Hex()] {
+			continue
+		}
+		if err = r.apply(&models.Operation{Type: OperationTypeCatalogs, Method: OperationMethodDelete, Catalog: catalog}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &drift, nil
+}
+
+// inMemory returns catalogs of the category from memory storage.
+func (r *replicator) inMemory(category string) []*models.Catalog {
+	catalogs, _ := r.memStore.GetCatalogByCategoryAndQuery(category, "", false)
+
+	res := make([]*models.Catalog, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		if catalog.Category == category {
+			res = append(res, catalog)
+		}
+	}
+
+	return res
+}
+
+// checksum returns a hash of catalogs which does not depend on their order.
+func checksum(catalogs []*models.Catalog) []byte {
+	sorted := make([]*models.Catalog, len(catalogs))
+	copy(sorted, catalogs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID.Hex() < sorted[j].ID.Hex()
+	})
+
+	h := sha256.New()
+	for _, catalog := range sorted {
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00%d\x00",
+			catalog.ID.Hex(), catalog.Version, catalog.Category, catalog.Name, catalog.Desc, catalog.Value, catalog.EffectiveStatus(),
+			catalog.UpdatedAt.Truncate(time.Millisecond).UnixNano())
+
+		if catalog.ParentID != nil {
+			_, _ = fmt.Fprintf(h, "%s\x00", catalog.ParentID.Hex())
+		}
+
+		locales := make([]string, 0, len(catalog.Translations))
+		for locale := range catalog.Translations {
+			locales = append(locales, locale)
+		}
+		sort.Strings(locales)
+		for _, locale := range locales {
+			translation := catalog.Translations[locale]
+			_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00", locale, translation.Name, translation.Desc)
+		}
+	}
+
+	return h.Sum(nil)
+}
+
+// diff counts missing, extra and changed catalogs of the category.
+func diff(category string, expected, actual []*models.Catalog) CategoryDrift {
+	drift := CategoryDrift{Category: category}
+
+	inMemory := make(map[string]*models.Catalog, len(actual))
+	for _, catalog := range actual {
+		inMemory[catalog.ID.Hex()] = catalog
+	}
+
+	for _, catalog := range expected {
+		current, ok := inMemory[catalog.ID.Hex()]
+		if !ok {
+			drift.Missing++
+			continue
+		}
+		delete(inMemory, catalog.ID.Hex())
+
+		if !bytes.Equal(checksum([]*models.Catalog{catalog}), checksum([]*models.Catalog{current})) {
+			drift.Changed++
+		}
+	}
+
+	drift.Extra = len(inMemory)
+
+	return drift
+}
@@ -0,0 +1,104 @@
+package replicator
+
+import (
+	"context"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestReconcileReloadsDriftedCategories(t *testing.T) {
+	repo := &fakeRepo{}
+	r := newTestReplicator(&fakeSource{}, repo, &fakeDeadLetters{}, config.Replication{})
+
+	if _, err := r.Reconcile(context.Background()); err != errNotReady {
+		t.Fatalf("Reconcile before ready: %v, want errNotReady", err)
+	}
+
+	unchanged := newCatalog("sizes", "small", 1)
+	edited := newCatalog("colors", "A1", 1)
+	renamed := newCatalog("colors", "red", 1)
+	missing := newCatalog("colors", "green", 1)
+	extra := newCatalog("colors", "blue", 1)
+	newer := newCatalog("colors", "white", 1)
+
+	if err := r.load([]*models.Catalog{unchanged, edited, renamed, extra, newer}, 0); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	// Replication applied a version Mongo was not read with yet
+	if err := r.processOperation(operation(OperationMethodUpsert, newer, 3), 1); err != nil {
+		t.Fatalf("processOperation: %v", err)
+	}
+
+	// Events of these changes were missed. The value keeps its length and the translation
+	// doesn't change name, description or value.
+	editedInMongo := *edited
+	editedInMongo.Value = "B2"
+	editedInMongo.Version = 2
+	editedInMongo.UpdatedAt = edited.UpdatedAt.Add(time.Minute)
+
+	renamedInMongo := *renamed
+	renamedInMongo.Translations = map[string]models.Translation{"ru": {Name: "красный"}}
+	renamedInMongo.Version = 2
+	renamedInMongo.UpdatedAt = renamed.UpdatedAt.Add(time.Minute)
+
+	newerInMongo := *newer
+	newerInMongo.Version = 2
+
+	repo.catalogs = []*models.Catalog{unchanged, &editedInMongo, &renamedInMongo, missing, &newerInMongo}
+	r.setReady(true)
+
+	report, err := r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	if report.Categories != 2 || len(report.Drifted) != 1 {
+		t.Fatalf("report = %+v, want one drifted category of two", report)
+	}
+	if drift := report.Drifted[0]; drift != (CategoryDrift{Category: "colors", Missing: 1, Extra: 1, Changed: 3}) {
+		t.Fatalf("drift = %+v", drift)
+	}
+	if repo.reads["sizes"] != 0 || repo.reads["colors"] != 1 {
+		t.Fatalf("categories read %v, want only the drifted one", repo.reads)
+	}
+
+	if c, _ := r.memStore.GetCatalog(edited.ID.String()); c == nil || c.Value != "B2" {
+		t.Fatalf("edited catalog = %+v, want value B2", c)
+	}
+	if c, _ := r.memStore.GetCatalog(renamed.ID.String()); c == nil || c.Translations["ru"].Name != "красный" {
+		t.Fatalf("renamed catalog = %+v, want the translation", c)
+	}
+	if version := stored(r, missing); version != 1 {
+		t.Fatalf("missing catalog has version %d, want 1", version)
+	}
+	if version := stored(r, extra); version != -1 {
+		t.Fatalf("extra catalog is stored with version %d", version)
+	}
+	if version := stored(r, newer); version != 3 {
+		t.Fatalf("newer catalog has version %d, want the replicated 3", version)
+	}
+	if r.DriftedCategories() != 1 || r.LastReconcile() != report {
+		t.Fatalf("%d drifted categories, last report %p", r.DriftedCategories(), r.LastReconcile())
+	}
+
+	// Only the newer catalog still differs, it is compared again and kept
+	report, err = r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(report.Drifted) != 1 || report.Drifted[0].Changed != 1 || stored(r, newer) != 3 {
+		t.Fatalf("report = %+v, newer catalog version %d", report, stored(r, newer))
+	}
+
+	// Once Mongo has the replicated version nothing is read
+	newerInMongo.Version = 3
+	report, err = r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(report.Drifted) != 0 || repo.reads["colors"] != 2 {
+		t.Fatalf("report = %+v, categories read %v", report, repo.reads)
+	}
+}
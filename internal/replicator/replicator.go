@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/rusrafkasimov/catalogs/internal/config"
 	"github.com/rusrafkasimov/catalogs/internal/queue"
 	"github.com/rusrafkasimov/catalogs/internal/trace"
 	"github.com/rusrafkasimov/catalogs/pkg/models"
 	"github.com/rusrafkasimov/catalogs/pkg/repository/memstore"
 	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+	"sync"
 	"sync/atomic"
 
 	"github.com/afiskon/promtail-client/promtail"
@@ -21,30 +23,90 @@ const (
 	OperationMethodDelete models.OperationMethod = "delete"
 )
 
+var errNoOperation = errors.New("event has no operation")
+
+// Source delivers replication events. It is the event queue or the Mongo change stream.
+type Source interface {
+	Subscribe() (<-chan queue.Event, error)
+}
+
+// CommitOrdered is a replication source delivering every write of the storage in commit order with
+// growing sequences, like the Mongo change stream. Its events are ordered by sequence, since writes
+// made around the service don't bump the catalog version.
+type CommitOrdered interface {
+	CommitOrdered() bool
+}
+
 type Replicator interface {
-	Replicate() error
-	loadDataFromStorage(ctx context.Context) error
-	loadCatalogs(ctx context.Context) error
+	Replicate(ctx context.Context) error
+	Ready() bool
+	StaleOperations() uint64
+	LastSequence(id string) (uint64, bool)
+	Reconcile(ctx context.Context) (*ReconcileReport, error)
+	LastReconcile() *ReconcileReport
+	DriftedCategories() uint64
+	DeadLettered() uint64
+}
+
+// catalogState is the last applied change of one catalog.
+type catalogState struct {
+	version  int64
+	sequence uint64
 }
 
 type replicator struct {
+	// 64-bit counters go first to stay aligned for atomic access
+	stale        uint64
+	drifted      uint64
+	// Highest handled sequence, saved with snapshots to resume the source. It has no meaning for
+	// partitioned sources such as Kafka, their sequences are offsets within a partition.
+	lastSequence uint64
+	deadLettered uint64
+
 	ctx            context.Context
 	repo           repository.CatalogsRepository
 	memStore       memstore.MemStore
-	eventQueue     queue.EventQueue
+	source         Source
+	deadLetters    DeadLetterSink
+	node           string
 	logger         promtail.Client
 	ready          uint32
 	operationTypes map[models.OperationType]bool
+
+	mu       sync.RWMutex
+	catalogs map[string]catalogState
+
+	cfg         config.Replication
+	reconcileMu sync.Mutex
+	reportMu    sync.Mutex
+	lastReport  *ReconcileReport
+
+	// Delivery attempts of the event being handled, events are delivered one at a time
+	failedEvent    eventKey
+	failedAttempts int
 }
 
-func New(ctx context.Context, repo repository.CatalogsRepository, memStore memstore.MemStore, eventQueue queue.EventQueue, logger promtail.Client, operationTypes []models.OperationType) *replicator {
+// New creates a replicator. With cfg.ReconcileInterval memory storage is compared with Mongo
+// periodically, with cfg.SnapshotPath it is saved to disk and restored on start.
+func New(ctx context.Context, repo repository.CatalogsRepository, memStore memstore.MemStore, source Source, deadLetters DeadLetterSink, logger promtail.Client, operationTypes []models.OperationType, cfg config.Replication) *replicator {
 
 	types := make(map[models.OperationType]bool, len(operationTypes))
 	for _, ot := range operationTypes {
 		types[ot] = true
 	}
 
-	return &replicator{ctx: ctx, repo: repo, memStore: memStore, eventQueue: eventQueue, logger: logger, operationTypes: types}
+	return &replicator{
+		ctx:            ctx,
+		repo:           repo,
+		memStore:       memStore,
+		source:         source,
+		deadLetters:    deadLetters,
+		node:           ctx.Value("Name").(string),
+		logger:         logger,
+		operationTypes: types,
+		catalogs:       make(map[string]catalogState),
+		cfg:            cfg,
+	}
 }
 
 // setReady set atomic ready value
@@ -62,20 +124,79 @@ func (r *replicator) Ready() bool {
 	return atomic.LoadUint32(&r.ready) == 1
 }
 
+// StaleOperations returns the number of operations skipped because a newer version was already applied.
+func (r *replicator) StaleOperations() uint64 {
+	return atomic.LoadUint64(&r.stale)
+}
+
+// LastSequence returns the event sequence of the last operation applied to the catalog. Zero means
+// the catalog was loaded from the storage. For partitioned sources it is the offset in the partition
+// of the catalog.
+func (r *replicator) LastSequence(id string) (uint64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state, ok := r.catalogs[id]
+
+	return state.sequence, ok
+}
+
 // Replicate start loading data from storage and check replication events
 func (r *replicator) Replicate(ctx context.Context) error {
-	err := r.loadDataFromStorage(ctx)
+	if err := r.loadDataFromStorage(ctx); err != nil {
+		return err
+	}
+
 	r.setReady(true)
+	defer r.setReady(false)
+
+	if r.cfg.ReconcileInterval > 0 && r.operationTypes[OperationTypeCatalogs] {
+		stopReconcile := r.background(ctx, func(ctx context.Context) {
+			r.reconcile(ctx, r.cfg.ReconcileInterval)
+		})
+		defer stopReconcile()
+	}
+
+	if r.snapshotsEnabled() {
+		stopSnapshots := r.background(ctx, func(ctx context.Context) {
+			r.snapshots(ctx, r.cfg.SnapshotInterval)
+		})
+		defer stopSnapshots()
+	}
 
 	if err := r.handleReplicationEvents(ctx); err != nil {
-		return fmt.Errorf("failed to handle replication events from event queue: %w", err)
+		return fmt.Errorf("failed to handle replication events: %w", err)
 	}
 
-	return err
+	return nil
+}
+
+// background runs fn in a goroutine and returns a function which stops it and waits.
+func (r *replicator) background(ctx context.Context, fn func(ctx context.Context)) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
 }
 
-// loadDataFromStorage start loading all data to storage
+// loadDataFromStorage restores the snapshot or loads all data from storage
 func (r *replicator) loadDataFromStorage(ctx context.Context) error {
+	if r.snapshotsEnabled() {
+		err := r.restoreSnapshot()
+		if err == nil {
+			return nil
+		}
+		r.logger.Warnf("Replicator: can't restore snapshot, loading catalogs from storage. %s", err.Error())
+	}
+
 	if err := r.loadCatalogs(ctx); err != nil {
 		return fmt.Errorf("failed to load data: %w", err)
 	}
@@ -100,47 +221,139 @@ func (r *replicator) loadCatalogs(ctx context.Context) error {
 		return err
 	}
 
-	for _, entry := range refBooks {
-		err = r.processOperation(&models.Operation{
-			Type:    OperationTypeCatalogs,
-			Method:  OperationMethodUpsert,
-			Catalog: entry,
-		})
-		if err != nil {
-			return err
+	return r.load(refBooks, 0)
+}
+
+// load stores catalogs in memory storage at once and records their versions. Catalogs older than
+// the applied versions are skipped like stale operations.
+func (r *replicator) load(catalogs []*models.Catalog, seq uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fresh := make([]*models.Catalog, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		if catalog == nil || catalog.ID.IsZero() {
+			return fmt.Errorf("%s operation has no catalog", OperationMethodUpsert)
+		}
+
+		if state, ok := r.catalogs[catalog.ID.Hex()]; ok && r.isStale(catalog.Version, seq, state) {
+			atomic.AddUint64(&r.stale, 1)
+			continue
+		}
+		fresh = append(fresh, catalog)
+	}
+
+	r.memStore.LoadCatalogs(fresh)
+
+	for _, catalog := range fresh {
+		if _, ok := r.memStore.GetCatalog(catalog.ID.String()); !ok {
+			return fmt.Errorf("catalog %s was not stored in memory storage", catalog.ID.Hex())
 		}
+		r.advance(catalog, seq)
 	}
 
 	return nil
 }
 
-// processOperation depending on the operation type make changes to the memory storage
-func (r *replicator) processOperation(op *models.Operation) (err error) {
-
-	if op.Catalog == nil {
-		return nil
+// processOperation depending on the operation type make changes to the memory storage.
+// Operations older than the applied version of the catalog are skipped. An operation which fails
+// to apply is not recorded, so its redelivery is applied again.
+func (r *replicator) processOperation(op *models.Operation, seq uint64) error {
+	if op == nil {
+		return errNoOperation
 	}
 
 	switch op.Type {
 	case OperationTypeCatalogs:
-		switch op.Method {
-		case OperationMethodDelete:
-			r.memStore.RemoveCatalog(op.Catalog.ID.String())
+		if op.Catalog == nil || op.Catalog.ID.IsZero() {
+			return fmt.Errorf("%s operation has no catalog", op.Method)
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if state, ok := r.catalogs[op.Catalog.ID.Hex()]; ok && r.isStale(op.Catalog.Version, seq, state) {
+			atomic.AddUint64(&r.stale, 1)
+			return nil
+		}
+
+		if err := r.apply(op); err != nil {
+			return err
+		}
+		r.advance(op.Catalog, seq)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown operation type %q", op.Type)
+	}
+}
+
+// apply changes memory storage by the catalog operation and checks the result. The caller holds r.mu.
+func (r *replicator) apply(op *models.Operation) error {
+	id := op.Catalog.ID.String()
+
+	switch op.Method {
+	case OperationMethodDelete:
+		r.memStore.RemoveCatalog(id)
+		if _, ok := r.memStore.GetCatalog(id); ok {
+			return fmt.Errorf("catalog %s was not removed from memory storage", op.Catalog.ID.Hex())
+		}
 
-		case OperationMethodUpsert:
-			r.memStore.UpsertCatalog(op.Catalog)
-			r.memStore.UpsertCatalogByCategory(op.Catalog)
+	case OperationMethodUpsert:
+		// Drop the catalog from its previous category when it was moved
+		if prev, ok := r.memStore.GetCatalog(id); ok && prev.Category != op.Catalog.Category {
+			r.memStore.RemoveCatalog(id)
 		}
+		r.memStore.UpsertCatalog(op.Catalog)
+		r.memStore.UpsertCatalogByCategory(op.Catalog)
+		if _, ok := r.memStore.GetCatalog(id); !ok {
+			return fmt.Errorf("catalog %s was not stored in memory storage", op.Catalog.ID.Hex())
+		}
+
+	default:
+		return fmt.Errorf("unknown operation method %q", op.Method)
 	}
 
 	return nil
 }
 
+// advance records the applied version and sequence of the catalog. The caller holds r.mu.
+func (r *replicator) advance(catalog *models.Catalog, seq uint64) {
+	id := catalog.ID.Hex()
+
+	state := r.catalogs[id]
+	if catalog.Version > state.version {
+		state.version = catalog.Version
+	}
+	state.sequence = seq
+	r.catalogs[id] = state
+}
+
+// isStale reports whether the operation with the version and the sequence is older than the applied state.
+// Events of commit ordered sources are compared by sequence, catalogs loaded from the storage have
+// no sequence and only older versions are skipped. Events of other sources are compared by version,
+// deletes carry the version following the deleted one. Operations without version were published
+// before catalogs were versioned and are skipped once the version of the catalog is known.
+func (r *replicator) isStale(version int64, seq uint64, state catalogState) bool {
+	if ordered, ok := r.source.(CommitOrdered); ok && ordered.CommitOrdered() {
+		if seq != 0 && state.sequence != 0 {
+			return seq <= state.sequence
+		}
+		return version != 0 && version < state.version
+	}
+
+	if version == 0 {
+		return state.version != 0
+	}
+
+	return version <= state.version
+}
+
 // handleReplicationEvents subscribe service on replication events and handle events
 func (r *replicator) handleReplicationEvents(ctx context.Context) error {
-	eventCh, err := r.eventQueue.Subscribe()
+	eventCh, err := r.source.Subscribe()
 	if err != nil {
-		return fmt.Errorf("failed to subscribe event queue: %w", err)
+		return fmt.Errorf("failed to subscribe replication source: %w", err)
 	}
 
 	for {
@@ -150,16 +363,13 @@ func (r *replicator) handleReplicationEvents(ctx context.Context) error {
 				return errors.New("event channel is closed")
 			}
 
-			err := r.processOperation(evt.Operation())
-			if err != nil {
+			if err := r.handleEvent(ctx, evt); err != nil {
 				trace.OnError(r.logger, nil, err)
 				continue
 			}
 
-			err = evt.Ack()
-			if err != nil {
-				trace.OnError(r.logger, nil, err)
-				continue
+			if seq := evt.Sequence(); seq > atomic.LoadUint64(&r.lastSequence) {
+				atomic.StoreUint64(&r.lastSequence, seq)
 			}
 
 		case <-ctx.Done():
@@ -0,0 +1,302 @@
+package replicator
+
+import (
+	"context"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/internal/queue"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"github.com/rusrafkasimov/catalogs/pkg/repository/memstore"
+	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+// nopLogger discards log messages.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Shutdown()                     {}
+
+// fakeRepo serves catalogs from a slice, other methods of the repository are not implemented.
+type fakeRepo struct {
+	repository.CatalogsRepository
+
+	catalogs []*models.Catalog
+	// reads counts FindCatalogsByCategory calls by category
+	reads map[string]int
+}
+
+func (r *fakeRepo) FindCatalogsByCategory(_ context.Context, category string, _ opentracing.Span) ([]*models.Catalog, error) {
+	if r.reads == nil {
+		r.reads = make(map[string]int)
+	}
+	r.reads[category]++
+
+	var res []*models.Catalog
+	for _, catalog := range r.catalogs {
+		if category == "" || catalog.Category == category {
+			stored := *catalog
+			res = append(res, &stored)
+		}
+	}
+
+	return res, nil
+}
+
+func (r *fakeRepo) CatalogStamps(_ context.Context, _ opentracing.Span) (map[string]repository.CategoryStamps, error) {
+	byCategory := make(map[string][]*models.Catalog)
+	for _, catalog := range r.catalogs {
+		byCategory[catalog.Category] = append(byCategory[catalog.Category], catalog)
+	}
+
+	stamps := make(map[string]repository.CategoryStamps, len(byCategory))
+	for category, catalogs := range byCategory {
+		stamps[category] = repository.NewCategoryStamps(catalogs)
+	}
+
+	return stamps, nil
+}
+
+// fakeSource delivers events of the channel.
+type fakeSource struct {
+	events chan queue.Event
+}
+
+func (s *fakeSource) Subscribe() (<-chan queue.Event, error) {
+	return s.events, nil
+}
+
+// orderedSource is a commit ordered source like the change stream.
+type orderedSource struct {
+	fakeSource
+}
+
+func (s *orderedSource) CommitOrdered() bool {
+	return true
+}
+
+// testEvent is an event which counts acknowledgements.
+type testEvent struct {
+	op   *models.Operation
+	seq  uint64
+	acks int
+}
+
+func (e *testEvent) Operation() *models.Operation {
+	return e.op
+}
+
+func (e *testEvent) Sequence() uint64 {
+	return e.seq
+}
+
+func (e *testEvent) Ack() error {
+	e.acks++
+	return nil
+}
+
+// fakeDeadLetters keeps stored dead letters.
+type fakeDeadLetters struct {
+	letters []*models.DeadLetter
+}
+
+func (d *fakeDeadLetters) AddDeadLetter(_ context.Context, deadLetter *models.DeadLetter) error {
+	d.letters = append(d.letters, deadLetter)
+	return nil
+}
+
+// newTestReplicator returns a replicator of catalogs with an empty memory storage.
+func newTestReplicator(source Source, repo repository.CatalogsRepository, deadLetters DeadLetterSink, cfg config.Replication) *replicator {
+	ctx := context.WithValue(context.Background(), "Name", "node")
+
+	return New(ctx, repo, memstore.NewMemStore(ctx), source, deadLetters, nopLogger{}, []models.OperationType{OperationTypeCatalogs}, cfg)
+}
+
+func newCatalog(category, value string, version int64) *models.Catalog {
+	return &models.Catalog{
+		ID:        primitive.NewObjectID(),
+		Active:    true,
+		Category:  category,
+		Name:      value,
+		Value:     value,
+		Version:   version,
+		UpdatedAt: time.Date(2021, 1, 1, 0, 0, int(version), 0, time.UTC),
+	}
+}
+
+// operation returns the operation of the method with a copy of the catalog of the version.
+func operation(method models.OperationMethod, catalog *models.Catalog, version int64) *models.Operation {
+	c := *catalog
+	c.Version = version
+
+	return &models.Operation{Type: OperationTypeCatalogs, Method: method, Catalog: &c}
+}
+
+// stored returns the version of the catalog in memory storage, -1 when it is not stored.
+func stored(r *replicator, catalog *models.Catalog) int64 {
+	c, ok := r.memStore.GetCatalog(catalog.ID.String())
+	if !ok {
+		return -1
+	}
+
+	return c.Version
+}
+
+func TestIsStale(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordered bool
+		version int64
+		seq     uint64
+		state   catalogState
+		stale   bool
+	}{
+		{name: "newer version", version: 3, seq: 1, state: catalogState{version: 2, sequence: 9}},
+		{name: "same version", version: 2, seq: 10, state: catalogState{version: 2, sequence: 9}, stale: true},
+		{name: "older version", version: 1, seq: 10, state: catalogState{version: 2}, stale: true},
+		{name: "delete of the version", version: 3, state: catalogState{version: 2}},
+		{name: "unversioned before versions", version: 0, seq: 5, state: catalogState{}},
+		{name: "unversioned after versions", version: 0, seq: 5, state: catalogState{version: 1}, stale: true},
+
+		{name: "later sequence", ordered: true, version: 1, seq: 10, state: catalogState{version: 2, sequence: 9}},
+		{name: "same sequence", ordered: true, version: 3, seq: 9, state: catalogState{version: 2, sequence: 9}, stale: true},
+		{name: "earlier sequence", ordered: true, version: 3, seq: 8, state: catalogState{version: 2, sequence: 9}, stale: true},
+		{name: "event after load", ordered: true, version: 2, seq: 10, state: catalogState{version: 2}},
+		{name: "older event after load", ordered: true, version: 1, seq: 10, state: catalogState{version: 2}, stale: true},
+		{name: "unversioned event after load", ordered: true, version: 0, seq: 10, state: catalogState{version: 2}},
+		{name: "load after events", ordered: true, version: 2, state: catalogState{version: 3, sequence: 9}, stale: true},
+	}
+
+	for _, tt := range tests {
+		var source Source = &fakeSource{}
+		if tt.ordered {
+			source = &orderedSource{}
+		}
+		r := newTestReplicator(source, &fakeRepo{}, &fakeDeadLetters{}, config.Replication{})
+
+		if stale := r.isStale(tt.version, tt.seq, tt.state); stale != tt.stale {
+			t.Errorf("%s: isStale(%d, %d, %+v) = %v, want %v", tt.name, tt.version, tt.seq, tt.state, stale, tt.stale)
+		}
+	}
+}
+
+func TestProcessOperationSkipsStale(t *testing.T) {
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, &fakeDeadLetters{}, config.Replication{})
+	catalog := newCatalog("colors", "red", 1)
+
+	steps := []struct {
+		op    *models.Operation
+		want  int64
+		stale uint64
+	}{
+		{operation(OperationMethodUpsert, catalog, 2), 2, 0},
+		// A redelivered and a reordered event
+		{operation(OperationMethodUpsert, catalog, 2), 2, 1},
+		{operation(OperationMethodUpsert, catalog, 1), 2, 2},
+		// Deletes carry the version following the deleted one
+		{operation(OperationMethodDelete, catalog, 3), -1, 2},
+		{operation(OperationMethodUpsert, catalog, 2), -1, 3},
+		// Unversioned operations are skipped once the version is known
+		{operation(OperationMethodUpsert, catalog, 0), -1, 4},
+		{operation(OperationMethodDelete, catalog, 0), -1, 5},
+	}
+
+	for i, step := range steps {
+		if err := r.processOperation(step.op, uint64(i+1)); err != nil {
+			t.Fatalf("step %d: processOperation: %v", i, err)
+		}
+		if version := stored(r, catalog); version != step.want {
+			t.Fatalf("step %d: stored version %d, want %d", i, version, step.want)
+		}
+		if stale := r.StaleOperations(); stale != step.stale {
+			t.Fatalf("step %d: %d stale operations, want %d", i, stale, step.stale)
+		}
+	}
+
+	if seq, ok := r.LastSequence(catalog.ID.Hex()); !ok || seq != 4 {
+		t.Fatalf("LastSequence = %d, %v, want the sequence of the delete", seq, ok)
+	}
+}
+
+func TestProcessOperationUnversioned(t *testing.T) {
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, &fakeDeadLetters{}, config.Replication{})
+	catalog := newCatalog("colors", "red", 0)
+
+	// Catalogs published before versioning are applied until a version is known
+	if err := r.processOperation(operation(OperationMethodUpsert, catalog, 0), 1); err != nil {
+		t.Fatalf("processOperation: %v", err)
+	}
+	if err := r.processOperation(operation(OperationMethodDelete, catalog, 0), 2); err != nil {
+		t.Fatalf("processOperation: %v", err)
+	}
+	if version := stored(r, catalog); version != -1 {
+		t.Fatalf("unversioned delete was not applied, stored version %d", version)
+	}
+	if r.StaleOperations() != 0 {
+		t.Fatalf("%d stale operations, want none", r.StaleOperations())
+	}
+}
+
+func TestProcessOperationErrors(t *testing.T) {
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, &fakeDeadLetters{}, config.Replication{})
+	catalog := newCatalog("colors", "red", 1)
+
+	for name, op := range map[string]*models.Operation{
+		"no operation":   nil,
+		"unknown type":   {Type: "orders", Method: OperationMethodUpsert, Catalog: catalog},
+		"no catalog":     {Type: OperationTypeCatalogs, Method: OperationMethodUpsert},
+		"unknown method": operation("rename", catalog, 1),
+	} {
+		if err := r.processOperation(op, 1); err == nil {
+			t.Errorf("%s: processOperation succeeded", name)
+		}
+	}
+
+	// The failed operation is not recorded, so its redelivery is applied
+	if _, ok := r.LastSequence(catalog.ID.Hex()); ok {
+		t.Fatal("failed operation was recorded")
+	}
+	if err := r.processOperation(operation(OperationMethodUpsert, catalog, 1), 1); err != nil {
+		t.Fatalf("processOperation: %v", err)
+	}
+	if version := stored(r, catalog); version != 1 {
+		t.Fatalf("stored version %d, want 1", version)
+	}
+}
+
+func TestLoadSkipsOlderCatalogs(t *testing.T) {
+	r := newTestReplicator(&fakeSource{}, &fakeRepo{}, &fakeDeadLetters{}, config.Replication{})
+	applied := newCatalog("colors", "red", 1)
+	loaded := newCatalog("colors", "green", 1)
+
+	// An event applied before the load is newer than the loaded catalog
+	if err := r.processOperation(operation(OperationMethodUpsert, applied, 3), 7); err != nil {
+		t.Fatalf("processOperation: %v", err)
+	}
+
+	if err := r.load([]*models.Catalog{operation(OperationMethodUpsert, applied, 2).Catalog, loaded}, 0); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if version := stored(r, applied); version != 3 {
+		t.Fatalf("applied catalog has version %d after load, want 3", version)
+	}
+	if version := stored(r, loaded); version != 1 {
+		t.Fatalf("loaded catalog has version %d, want 1", version)
+	}
+	if r.StaleOperations() != 1 {
+		t.Fatalf("%d stale operations, want 1", r.StaleOperations())
+	}
+	if seq, _ := r.LastSequence(applied.ID.Hex()); seq != 7 {
+		t.Fatalf("LastSequence of the applied catalog = %d, want 7", seq)
+	}
+
+	if err := r.load([]*models.Catalog{{Category: "colors"}}, 0); err == nil {
+		t.Fatal("load of a catalog without ID succeeded")
+	}
+}
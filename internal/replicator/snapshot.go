@@ -0,0 +1,91 @@
+package replicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/repository/memstore"
+	"sync/atomic"
+	"time"
+)
+
+// Resumer is a replication source which can replay events starting at a sequence.
+type Resumer interface {
+	ResumeFrom(seq uint64) error
+}
+
+// snapshotsEnabled reports whether snapshots are configured and the source can replay events after them.
+func (r *replicator) snapshotsEnabled() bool {
+	if r.cfg.SnapshotPath == "" || !r.operationTypes[OperationTypeCatalogs] {
+		return false
+	}
+
+	_, ok := r.source.(Resumer)
+
+	return ok
+}
+
+// restoreSnapshot fills memory storage from the snapshot and resumes the source after its sequence.
+func (r *replicator) restoreSnapshot() error {
+	snapshot, err := memstore.ReadSnapshot(r.cfg.SnapshotPath)
+	if err != nil {
+		return err
+	}
+
+	if age := time.Since(snapshot.CreatedAt); r.cfg.SnapshotMaxAge > 0 && age > r.cfg.SnapshotMaxAge {
+		return fmt.Errorf("snapshot is too old: %v", age)
+	}
+
+	if err = r.load(snapshot.Catalogs, snapshot.Sequence); err != nil {
+		return err
+	}
+
+	atomic.StoreUint64(&r.lastSequence, snapshot.Sequence)
+
+	if err = r.source.(Resumer).ResumeFrom(snapshot.Sequence + 1); err != nil {
+		return fmt.Errorf("failed to resume replication source: %w", err)
+	}
+
+	r.logger.Infof("Replicator: restored %d catalogs from snapshot at sequence %d", len(snapshot.Catalogs), snapshot.Sequence)
+
+	return nil
+}
+
+// snapshots writes a snapshot every interval and once more when ctx is done.
+func (r *replicator) snapshots(ctx context.Context, interval time.Duration) {
+	tc := time.NewTicker(interval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+		case <-ctx.Done():
+			if err := r.writeSnapshot(); err != nil {
+				trace.OnError(r.logger, nil, err)
+			}
+			return
+		}
+
+		if err := r.writeSnapshot(); err != nil {
+			trace.OnError(r.logger, nil, err)
+		}
+	}
+}
+
+// writeSnapshot saves memory storage with the last applied sequence. Catalogs are read under
+// the replicator lock, so the snapshot never misses an event before the sequence.
+func (r *replicator) writeSnapshot() error {
+	r.mu.Lock()
+	snapshot := &memstore.Snapshot{
+		Sequence:  atomic.LoadUint64(&r.lastSequence),
+		CreatedAt: time.Now(),
+		Catalogs:  r.memStore.GetAllCatalogs(),
+	}
+	r.mu.Unlock()
+
+	if err := memstore.WriteSnapshot(r.cfg.SnapshotPath, snapshot); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package replicator
+
+import (
+	"context"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"github.com/rusrafkasimov/catalogs/pkg/repository/memstore"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// resumableSource is a source which replays events from a sequence, like the stan queue.
+type resumableSource struct {
+	fakeSource
+	from uint64
+}
+
+func (s *resumableSource) ResumeFrom(seq uint64) error {
+	s.from = seq
+	return nil
+}
+
+func TestSnapshotRestoreResumesSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalogs.snapshot")
+	cfg := config.Replication{SnapshotPath: path, SnapshotMaxAge: time.Hour}
+
+	saved := newCatalog("colors", "red", 2)
+	writer := newTestReplicator(&resumableSource{}, &fakeRepo{}, &fakeDeadLetters{}, cfg)
+	if err := writer.processOperation(operation(OperationMethodUpsert, saved, 2), 41); err != nil {
+		t.Fatalf("processOperation: %v", err)
+	}
+	atomic.StoreUint64(&writer.lastSequence, 41)
+	if err := writer.writeSnapshot(); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+
+	// Catalogs are restored from the snapshot instead of Mongo
+	source := &resumableSource{}
+	repo := &fakeRepo{catalogs: []*models.Catalog{newCatalog("colors", "green", 1)}}
+	r := newTestReplicator(source, repo, &fakeDeadLetters{}, cfg)
+	if err := r.loadDataFromStorage(context.Background()); err != nil {
+		t.Fatalf("loadDataFromStorage: %v", err)
+	}
+
+	if source.from != 42 || atomic.LoadUint64(&r.lastSequence) != 41 {
+		t.Fatalf("resumed from %d at last sequence %d, want 42 after 41", source.from, r.lastSequence)
+	}
+	if version := stored(r, saved); version != 2 {
+		t.Fatalf("restored catalog has version %d, want 2", version)
+	}
+	if len(r.memStore.GetAllCatalogs()) != 1 || repo.reads[""] != 0 {
+		t.Fatal("catalogs were loaded from Mongo")
+	}
+
+	// Replayed events up to the snapshot are skipped
+	if err := r.processOperation(operation(OperationMethodUpsert, saved, 2), 41); err != nil {
+		t.Fatalf("processOperation: %v", err)
+	}
+	if r.StaleOperations() != 1 {
+		t.Fatalf("%d stale operations, want the replayed one", r.StaleOperations())
+	}
+}
+
+func TestSnapshotTooOldLoadsFromStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalogs.snapshot")
+	cfg := config.Replication{SnapshotPath: path, SnapshotMaxAge: time.Hour}
+
+	snapshot := &memstore.Snapshot{
+		Sequence:  41,
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		Catalogs:  []*models.Catalog{newCatalog("colors", "red", 2)},
+	}
+	if err := memstore.WriteSnapshot(path, snapshot); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+
+	source := &resumableSource{}
+	loaded := newCatalog("colors", "green", 1)
+	repo := &fakeRepo{catalogs: []*models.Catalog{loaded}}
+	r := newTestReplicator(source, repo, &fakeDeadLetters{}, cfg)
+	if err := r.loadDataFromStorage(context.Background()); err != nil {
+		t.Fatalf("loadDataFromStorage: %v", err)
+	}
+
+	if source.from != 0 || repo.reads[""] != 1 {
+		t.Fatalf("resumed from %d, Mongo read %d times", source.from, repo.reads[""])
+	}
+	if all := r.memStore.GetAllCatalogs(); len(all) != 1 || all[0].ID != loaded.ID {
+		t.Fatalf("memory storage has %d catalogs, want the loaded one", len(all))
+	}
+}
+
+func TestSnapshotsNeedResumableSource(t *testing.T) {
+	cfg := config.Replication{SnapshotPath: filepath.Join(t.TempDir(), "catalogs.snapshot")}
+
+	if newTestReplicator(&fakeSource{}, &fakeRepo{}, &fakeDeadLetters{}, cfg).snapshotsEnabled() {
+		t.Fatal("snapshots are enabled for a source which can't resume")
+	}
+	if !newTestReplicator(&resumableSource{}, &fakeRepo{}, &fakeDeadLetters{}, cfg).snapshotsEnabled() {
+		t.Fatal("snapshots are disabled for a resumable source")
+	}
+	if newTestReplicator(&resumableSource{}, &fakeRepo{}, &fakeDeadLetters{}, config.Replication{}).snapshotsEnabled() {
+		t.Fatal("snapshots are enabled without a path")
+	}
+}
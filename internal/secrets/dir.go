@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirProvider reads secrets from a directory with one file per key, as mounted by Docker
+// and Kubernetes secrets. Key "database:password" is read from "<dir>/database/password",
+// key "mongo_password" from "<dir>/mongo_password".
+type DirProvider struct {
+	dir string
+}
+
+// NewDirProvider creates a provider for dir. The directory must exist.
+func NewDirProvider(dir string) (*DirProvider, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("secrets dir: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("secrets dir: %s is not a directory", dir)
+	}
+
+	return &DirProvider{dir: dir}, nil
+}
+
+// Get returns the content of the key file without trailing newlines.
+func (p *DirProvider) Get(key string) (string, error) {
+	parts := splitKey(key)
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." || strings.ContainsRune(part, filepath.Separator) {
+			return "", fmt.Errorf("invalid key %q", key)
+		}
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(append([]string{p.dir}, parts...)...))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%s: %w", key, errKeyNotFound)
+		}
+		return "", fmt.Errorf("reading: %w", err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// Name returns provider name for configuration reports.
+func (p *DirProvider) Name() string {
+	return ProviderDir
+}
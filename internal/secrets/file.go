@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileProvider reads secrets from a JSON or YAML file. Nested objects are addressed with colons,
+// so key "database:password" selects {"database": {"password": "..."}}.
+type FileProvider struct {
+	path string
+
+	mu   sync.RWMutex
+	data map[string]interface{}
+}
+
+// NewFileProvider reads and parses the file. The format is chosen by extension:
+// .yaml and .yml are parsed as YAML, everything else as JSON.
+func NewFileProvider(path string) (*FileProvider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("secrets file is not set")
+	}
+
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileProvider{path: path, data: data}, nil
+}
+
+// Get returns the scalar value found by key.
+func (p *FileProvider) Get(key string) (string, error) {
+	p.mu.RLock()
+	var node interface{} = p.data
+	p.mu.RUnlock()
+
+	for _, part := range splitKey(key) {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s: %w", key, errKeyNotFound)
+		}
+
+		node, ok = obj[part]
+		if !ok {
+			return "", fmt.Errorf("%s: %w", key, errKeyNotFound)
+		}
+	}
+
+	switch val := node.(type) {
+	case string:
+		return val, nil
+	case map[string]interface{}, []interface{}, nil:
+		return "", fmt.Errorf("%s: secret value is not scalar", key)
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
+
+// Invalidate reads the file again. The previous content is kept if the file can not be read or parsed.
+func (p *FileProvider) Invalidate() error {
+	data, err := readFile(p.path)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.data = data
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Name returns provider name for configuration reports.
+func (p *FileProvider) Name() string {
+	return ProviderFile
+}
+
+// readFile reads and parses secrets file.
+func readFile(path string) (map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("secrets file: %w", err)
+	}
+
+	data := make(map[string]interface{})
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		raw := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+		data = normalizeYAML(raw)
+	default:
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("parse json: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+// normalizeYAML converts YAML maps to the JSON representation used by Get.
+func normalizeYAML(raw map[interface{}]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		if nested, ok := v.(map[interface{}]interface{}); ok {
+			res[fmt.Sprint(k)] = normalizeYAML(nested)
+			continue
+		}
+		res[fmt.Sprint(k)] = v
+	}
+
+	return res
+}
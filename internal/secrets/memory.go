@@ -0,0 +1,51 @@
+package secrets
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemoryProvider keeps secrets in memory. It is intended for tests and local runs.
+type MemoryProvider struct {
+	mu   sync.RWMutex
+	data map[string]string
+}
+
+// NewMemoryProvider creates a provider with a copy of data. Keys are used as is, e.g. "database:password".
+func NewMemoryProvider(data map[string]string) *MemoryProvider {
+	p := &MemoryProvider{
+		data: make(map[string]string, len(data)),
+	}
+
+	for k, v := range data {
+		p.data[k] = v
+	}
+
+	return p
+}
+
+// Get returns the secret stored under key.
+func (p *MemoryProvider) Get(key string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	val, ok := p.data[key]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", key, errKeyNotFound)
+	}
+
+	return val, nil
+}
+
+// Set stores the secret under key.
+func (p *MemoryProvider) Set(key, value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.data[key] = value
+}
+
+// Name returns provider name for configuration reports.
+func (p *MemoryProvider) Name() string {
+	return ProviderMemory
+}
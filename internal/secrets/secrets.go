@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/internal/vault"
+	"os"
+	"strings"
+)
+
+const (
+	ProviderVault  = "vault"
+	ProviderFile   = "file"
+	ProviderDir    = "dir"
+	ProviderMemory = "memory"
+
+	defaultSecretsDir = "/run/secrets"
+)
+
+var errKeyNotFound = errors.New("key not found")
+
+// NewProvider builds the secret provider selected by CONFIG_SECRET_PROVIDER. It is read directly
+// from ENV because the provider is needed before the configuration can be loaded.
+//
+//	vault  - HashiCorp Vault KV engine (default)
+//	file   - JSON or YAML file from CONFIG_SECRET_FILE
+//	dir    - one file per key in CONFIG_SECRET_DIR, /run/secrets by default
+//	memory - empty in-memory provider, every `<key>_SECURE` lookup fails
+func NewProvider() (config.Provider, error) {
+	var (
+		provider config.Provider
+		err      error
+	)
+
+	kind := os.Getenv("CONFIG_SECRET_PROVIDER")
+	if kind == "" {
+		kind = ProviderVault
+	}
+
+	switch kind {
+	case ProviderVault:
+		provider, err = vault.NewVaultProvider()
+
+	case ProviderFile:
+		provider, err = NewFileProvider(os.Getenv("CONFIG_SECRET_FILE"))
+
+	case ProviderDir:
+		dir := os.Getenv("CONFIG_SECRET_DIR")
+		if dir == "" {
+			dir = defaultSecretsDir
+		}
+		provider, err = NewDirProvider(dir)
+
+	case ProviderMemory:
+		provider = NewMemoryProvider(nil)
+
+	default:
+		return nil, fmt.Errorf("unknown secret provider %q", kind)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s provider: %w", kind, err)
+	}
+
+	return provider, nil
+}
+
+// splitKey splits the `<path>:<key>` form used by `<key>_SECURE` values into path segments.
+func splitKey(v string) []string {
+	return strings.Split(v, ":")
+}
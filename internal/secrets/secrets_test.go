@@ -0,0 +1,253 @@
+package secrets
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name in dir, creating parent directories.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// setenv sets the variable for the test and restores its previous value.
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+
+	prev, ok := os.LookupEnv(key)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+			return
+		}
+		os.Unsetenv(key)
+	})
+
+	os.Setenv(key, value)
+}
+
+// lookups checks values of keys, an empty value means the lookup must fail.
+func lookups(t *testing.T, get func(key string) (string, error), want map[string]string) {
+	t.Helper()
+
+	for key, value := range want {
+		got, err := get(key)
+		if value == "" {
+			if err == nil {
+				t.Errorf("Get(%q) = %q, want an error", key, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Get(%q): %v", key, err)
+			continue
+		}
+		if got != value {
+			t.Errorf("Get(%q) = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestFileProviderJSON(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "secrets.json", `{
+		"mongo_password": "pass",
+		"database": {"user": "catalogs", "port": 27017, "tls": true, "hosts": ["a", "b"], "empty": null}
+	}`)
+
+	p, err := NewFileProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileProvider: %v", err)
+	}
+
+	lookups(t, p.Get, map[string]string{
+		"mongo_password":   "pass",
+		"database:user":    "catalogs",
+		"database:port":    "27017",
+		"database:tls":     "true",
+		"database":         "",
+		"database:hosts":   "",
+		"database:empty":   "",
+		"database:missing": "",
+		"mongo_password:x": "",
+		"missing":          "",
+		"missing:password": "",
+	})
+
+	if _, err := p.Get("missing"); !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("Get of a missing key: %v, want errKeyNotFound", err)
+	}
+	if _, err := p.Get("database"); err == nil || !strings.Contains(err.Error(), "not scalar") {
+		t.Fatalf("Get of an object: %v, want a not scalar error", err)
+	}
+}
+
+func TestFileProviderYAML(t *testing.T) {
+	for _, name := range []string{"secrets.yaml", "secrets.YML"} {
+		path := writeFile(t, t.TempDir(), name, "mongo_password: pass\ndatabase:\n  user: catalogs\n  port: 27017\n  hosts:\n    - a\n")
+
+		p, err := NewFileProvider(path)
+		if err != nil {
+			t.Fatalf("NewFileProvider(%s): %v", name, err)
+		}
+
+		lookups(t, p.Get, map[string]string{
+			"mongo_password": "pass",
+			"database:user":  "catalogs",
+			"database:port":  "27017",
+			"database":       "",
+			"database:hosts": "",
+			"missing":        "",
+		})
+	}
+}
+
+func TestFileProviderErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := NewFileProvider(""); err == nil {
+		t.Fatal("NewFileProvider without a path succeeded")
+	}
+	if _, err := NewFileProvider(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatal("NewFileProvider of a missing file succeeded")
+	}
+	if _, err := NewFileProvider(writeFile(t, dir, "broken.json", "{")); err == nil {
+		t.Fatal("NewFileProvider of broken JSON succeeded")
+	}
+	if _, err := NewFileProvider(writeFile(t, dir, "broken.yaml", "a: [")); err == nil {
+		t.Fatal("NewFileProvider of broken YAML succeeded")
+	}
+}
+
+func TestFileProviderInvalidate(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "secrets.json", `{"password": "old"}`)
+
+	p, err := NewFileProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileProvider: %v", err)
+	}
+
+	writeFile(t, filepath.Dir(path), "secrets.json", `{"password": "new"}`)
+	if err := p.Invalidate(); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	lookups(t, p.Get, map[string]string{"password": "new"})
+
+	// Content which can't be parsed is reported and the previous secrets are kept
+	writeFile(t, filepath.Dir(path), "secrets.json", `{"password": `)
+	if err := p.Invalidate(); err == nil {
+		t.Fatal("Invalidate of broken JSON succeeded")
+	}
+	lookups(t, p.Get, map[string]string{"password": "new"})
+}
+
+func TestDirProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "mongo_password", "pass\n")
+	writeFile(t, dir, "database/user", "catalogs\r\n")
+	writeFile(t, dir, "database/note", "line one\nline two")
+
+	p, err := NewDirProvider(dir)
+	if err != nil {
+		t.Fatalf("NewDirProvider: %v", err)
+	}
+
+	lookups(t, p.Get, map[string]string{
+		"mongo_password":   "pass",
+		"database:user":    "catalogs",
+		"database:note":    "line one\nline two",
+		"database":         "",
+		"database:missing": "",
+		"missing":          "",
+		"":                 "",
+		"..:etc":           "",
+		"database::user":   "",
+		"database/user":    "",
+	})
+
+	if _, err := p.Get("missing"); !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("Get of a missing key: %v, want errKeyNotFound", err)
+	}
+
+	if _, err := NewDirProvider(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("NewDirProvider of a missing dir succeeded")
+	}
+	if _, err := NewDirProvider(filepath.Join(dir, "mongo_password")); err == nil {
+		t.Fatal("NewDirProvider of a file succeeded")
+	}
+}
+
+func TestMemoryProvider(t *testing.T) {
+	data := map[string]string{"database:password": "pass"}
+	p := NewMemoryProvider(data)
+
+	// The provider keeps a copy of data
+	data["database:password"] = "changed"
+	p.Set("mongo_password", "secret")
+
+	lookups(t, p.Get, map[string]string{
+		"database:password": "pass",
+		"mongo_password":    "secret",
+		"database":          "",
+		"missing":           "",
+	})
+
+	if _, err := p.Get("missing"); !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("Get of a missing key: %v, want errKeyNotFound", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "secrets.json", `{"password": "pass"}`)
+
+	tests := []struct {
+		kind, file, dir string
+		name            string
+		fails           bool
+	}{
+		{kind: ProviderFile, file: file, name: ProviderFile},
+		{kind: ProviderFile, fails: true},
+		{kind: ProviderDir, dir: dir, name: ProviderDir},
+		{kind: ProviderDir, dir: filepath.Join(dir, "missing"), fails: true},
+		{kind: ProviderMemory, name: ProviderMemory},
+		{kind: "consul", fails: true},
+	}
+
+	for _, tt := range tests {
+		setenv(t, "CONFIG_SECRET_PROVIDER", tt.kind)
+		setenv(t, "CONFIG_SECRET_FILE", tt.file)
+		setenv(t, "CONFIG_SECRET_DIR", tt.dir)
+
+		provider, err := NewProvider()
+		if tt.fails {
+			if err == nil {
+				t.Errorf("NewProvider(%s) succeeded", tt.kind)
+			} else if !strings.Contains(err.Error(), tt.kind) {
+				t.Errorf("NewProvider(%s) error %q does not name the provider", tt.kind, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewProvider(%s): %v", tt.kind, err)
+			continue
+		}
+
+		if named, ok := provider.(interface{ Name() string }); !ok || named.Name() != tt.name {
+			t.Errorf("NewProvider(%s) returned %T", tt.kind, provider)
+		}
+	}
+}
@@ -24,17 +24,7 @@ func OnError(logger promtail.Client, span opentracing.Span, err error) {
 	logger.Errorf("%v", err.Error())
 }
 
-func InitJaegerTracing(ctx context.Context, contextKeyName interface{}, configuration *config.Configuration) (closer io.Closer, err error) {
-	host, err := configuration.Get("JAEGER_AGENT_HOST")
-	if err != nil {
-		return nil, err
-	}
-
-	port, err := configuration.Get("JAEGER_AGENT_PORT")
-	if err != nil {
-		return nil, err
-	}
-
+func InitJaegerTracing(ctx context.Context, contextKeyName interface{}, agent config.Jaeger) (closer io.Closer, err error) {
 	cfg := jaegercfg.Configuration{
 		ServiceName: ctx.Value(contextKeyName).(string),
 		Sampler: &jaegercfg.SamplerConfig{
@@ -43,17 +33,20 @@ func InitJaegerTracing(ctx context.Context, contextKeyName interface{}, configur
 		},
 		Reporter: &jaegercfg.ReporterConfig{
 			LogSpans:           true,
-			LocalAgentHostPort: fmt.Sprintf("%s:%s", host, port),
+			LocalAgentHostPort: fmt.Sprintf("%s:%s", agent.Host, agent.Port),
 		},
 	}
 
 	jLogger := jaegerlog.StdLogger
 	jMetricsFactory := metrics.NullFactory
 
-	tracer, closer, _ := cfg.NewTracer(
+	tracer, closer, err := cfg.NewTracer(
 		jaegercfg.Logger(jLogger),
 		jaegercfg.Metrics(jMetricsFactory),
 	)
+	if err != nil {
+		return nil, err
+	}
 
 	opentracing.SetGlobalTracer(tracer)
 
@@ -5,59 +5,371 @@ import (
 	"fmt"
 	"github.com/hashicorp/vault/api"
 	"io/ioutil"
-	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+const (
+	AuthToken      = "token"
+	AuthAppRole    = "approle"
+	AuthKubernetes = "kubernetes"
+
+	defaultCacheTTL       = 5 * time.Minute
+	defaultReloginTimeout = 5 * time.Second
+	defaultK8sTokenPath   = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+var (
+	errNoAuth    = errors.New("no auth info in login response")
+	errLeaseOver = errors.New("token lease is over")
+)
+
+// Config describes how to connect and authenticate in Vault.
+type Config struct {
+	Address string
+	// Path is the mount path of the KV engine.
+	Path string
+	// KVVersion is the KV engine version, 1 or 2. Zero means 2.
+	KVVersion int
+
+	// AuthMethod is one of AuthToken, AuthAppRole or AuthKubernetes. Empty means AuthToken.
+	AuthMethod string
+	Token      string
+
+	AppRoleMount string
+	RoleID       string
+	SecretID     string
+
+	K8sMount     string
+	K8sRole      string
+	K8sTokenPath string
+
+	// CacheTTL is how long secrets are cached. Negative value disables expiration.
+	CacheTTL time.Duration
+}
+
+type cacheEntry struct {
+	secrets map[string]string
+	expires time.Time
+}
+
 type Provider struct {
-	path    string
-	client  *api.Logical
-	results map[string]map[string]string
+	cfg    Config
+	client *api.Client
+
+	mu      sync.RWMutex
+	results map[string]cacheEntry
+
+	// errMu guards onError and errors reported before it is set
+	errMu   sync.Mutex
+	onError func(err error)
+	pending []error
+
+	wg     sync.WaitGroup
+	doneCh chan struct{}
+	once   sync.Once
+
+	now func() time.Time
 }
 
-// NewVaultProvider initialize new vault provider
-func NewVaultProvider() *Provider {
+// NewVaultProvider initialize new vault provider from ENV
+func NewVaultProvider() (*Provider, error) {
 	content, err := ioutil.ReadFile("/run/secrets/vault_dev_root_token_id")
 	if err != nil {
 		content = []byte(os.Getenv("VAULT_TOKEN"))
 	}
 
-	vaultPath := os.Getenv("VAULT_PATH")
-	vaultToken := string(content)
-	vaultAddress := os.Getenv("VAULT_ADDRESS")
+	cfg := Config{
+		Address:      os.Getenv("VAULT_ADDRESS"),
+		Path:         os.Getenv("VAULT_PATH"),
+		AuthMethod:   os.Getenv("VAULT_AUTH_METHOD"),
+		Token:        strings.TrimSpace(string(content)),
+		AppRoleMount: os.Getenv("VAULT_APPROLE_MOUNT"),
+		RoleID:       os.Getenv("VAULT_ROLE_ID"),
+		SecretID:     os.Getenv("VAULT_SECRET_ID"),
+		K8sMount:     os.Getenv("VAULT_K8S_MOUNT"),
+		K8sRole:      os.Getenv("VAULT_K8S_ROLE"),
+		K8sTokenPath: os.Getenv("VAULT_K8S_TOKEN_PATH"),
+		CacheTTL:     defaultCacheTTL,
+	}
 
-	provider, err := New(vaultToken, vaultAddress, vaultPath)
+	if secretIDFile := os.Getenv("VAULT_SECRET_ID_FILE"); secretIDFile != "" {
+		content, err := ioutil.ReadFile(secretIDFile)
+		if err != nil {
+			return nil, fmt.Errorf("read secret id: %w", err)
+		}
+		cfg.SecretID = strings.TrimSpace(string(content))
+	}
+
+	if v := os.Getenv("VAULT_KV_VERSION"); v != "" {
+		if cfg.KVVersion, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("parse VAULT_KV_VERSION: %w", err)
+		}
+	}
+
+	if v := os.Getenv("VAULT_CACHE_TTL"); v != "" {
+		if cfg.CacheTTL, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("parse VAULT_CACHE_TTL: %w", err)
+		}
+	}
+
+	provider, err := NewWithConfig(cfg)
 	if err != nil {
-		log.Fatalln("Couldn't load provider", err)
+		return nil, fmt.Errorf("couldn't load provider: %w", err)
 	}
 
-	return provider
+	return provider, nil
 }
 
-// New prepare and create new vault provider
+// New prepare and create new vault provider with static token
 func New(token, addr, path string) (*Provider, error) {
-	config := &api.Config{
-		Address: addr,
+	return NewWithConfig(Config{
+		Address:  addr,
+		Path:     path,
+		Token:    token,
+		CacheTTL: defaultCacheTTL,
+	})
+}
+
+// NewWithConfig creates a provider, logs in with the configured auth method and starts background
+// token renewal. Close stops the renewal.
+func NewWithConfig(cfg Config) (*Provider, error) {
+	if cfg.KVVersion == 0 {
+		cfg.KVVersion = 2
+	}
+	if cfg.KVVersion != 1 && cfg.KVVersion != 2 {
+		return nil, fmt.Errorf("unsupported KV version %d", cfg.KVVersion)
+	}
+	if cfg.AuthMethod == "" {
+		cfg.AuthMethod = AuthToken
+	}
+	if cfg.AppRoleMount == "" {
+		cfg.AppRoleMount = AuthAppRole
+	}
+	if cfg.K8sMount == "" {
+		cfg.K8sMount = AuthKubernetes
+	}
+	if cfg.K8sTokenPath == "" {
+		cfg.K8sTokenPath = defaultK8sTokenPath
 	}
 
+	config := api.DefaultConfig()
+	config.Address = cfg.Address
+
 	client, err := api.NewClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("new client: %w", err)
 	}
 
-	client.SetToken(token)
+	p := &Provider{
+		cfg:     cfg,
+		client:  client,
+		results: make(map[string]cacheEntry),
+		doneCh:  make(chan struct{}),
+		now:     time.Now,
+	}
+
+	auth, err := p.login()
+	if err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	if auth != nil {
+		p.wg.Add(1)
+		go p.renew(auth)
+	}
+
+	return p, nil
+}
+
+// login authenticates the client. It returns the secret to be renewed or nil when the token
+// can not be renewed.
+func (p *Provider) login() (*api.Secret, error) {
+	switch p.cfg.AuthMethod {
+	case AuthToken:
+		p.client.SetToken(p.cfg.Token)
+
+		self, err := p.client.Auth().Token().LookupSelf()
+		if err != nil {
+			var respErr *api.ResponseError
+			if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusForbidden {
+				return nil, fmt.Errorf("lookup token: %w", err)
+			}
+
+			// The token may lack the lookup-self capability; it still can be used for reading,
+			// but its TTL is unknown and it is not renewed.
+			p.report(fmt.Errorf("can't look up token, renewal disabled: %w", err))
+			return nil, nil
+		}
+
+		renewable, _ := self.TokenIsRenewable()
+		ttl, _ := self.TokenTTL()
+		if !renewable || ttl <= 0 {
+			return nil, nil
+		}
+
+		return &api.Secret{
+			Auth: &api.SecretAuth{
+				ClientToken:   p.cfg.Token,
+				Renewable:     true,
+				LeaseDuration: int(ttl.Seconds()),
+			},
+		}, nil
+
+	case AuthAppRole:
+		return p.loginWith(p.cfg.AppRoleMount, map[string]interface{}{
+			"role_id":   p.cfg.RoleID,
+			"secret_id": p.cfg.SecretID,
+		})
+
+	case AuthKubernetes:
+		jwt, err := ioutil.ReadFile(p.cfg.K8sTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("read service account token: %w", err)
+		}
+
+		return p.loginWith(p.cfg.K8sMount, map[string]interface{}{
+			"role": p.cfg.K8sRole,
+			"jwt":  strings.TrimSpace(string(jwt)),
+		})
+	}
+
+	return nil, fmt.Errorf("unsupported auth method %q", p.cfg.AuthMethod)
+}
+
+// loginWith calls the login endpoint of the auth mount and sets the received token.
+func (p *Provider) loginWith(mount string, data map[string]interface{}) (*api.Secret, error) {
+	p.client.ClearToken()
+
+	secret, err := p.client.Logical().Write(fmt.Sprintf("auth/%s/login", mount), data)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret == nil || secret.Auth == nil {
+		return nil, errNoAuth
+	}
+
+	p.client.SetToken(secret.Auth.ClientToken)
+
+	return secret, nil
+}
+
+// renew keeps the token alive. When it can not be renewed anymore, login is repeated
+// for AppRole and Kubernetes auth.
+func (p *Provider) renew(auth *api.Secret) {
+	defer p.wg.Done()
+
+	for {
+		watcher, err := p.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: auth})
+		if err != nil {
+			p.report(fmt.Errorf("can't start token renewal: %w", err))
+			return
+		}
+
+		go watcher.Start()
+
+		err = p.watch(watcher)
+		watcher.Stop()
+
+		select {
+		case <-p.doneCh:
+			return
+		default:
+		}
+
+		if err == nil {
+			err = errLeaseOver
+		}
+		p.report(fmt.Errorf("token renewal stopped: %w", err))
+
+		if p.cfg.AuthMethod == AuthToken {
+			return
+		}
+
+		if auth = p.relogin(); auth == nil {
+			return
+		}
+	}
+}
+
+// watch waits until the watcher stops renewing the token or the provider is closed.
+func (p *Provider) watch(watcher *api.LifetimeWatcher) error {
+	for {
+		select {
+		case err := <-watcher.DoneCh():
+			return err
+		case <-watcher.RenewCh():
+		case <-p.doneCh:
+			return nil
+		}
+	}
+}
+
+// relogin repeats login until it succeeds or the provider is closed.
+func (p *Provider) relogin() *api.Secret {
+	tc := time.NewTicker(defaultReloginTimeout)
+	defer tc.Stop()
 
-	return &Provider{
-		path:    path,
-		client:  client.Logical(),
-		results: make(map[string]map[string]string),
-	}, nil
+	for {
+		auth, err := p.login()
+		if err == nil {
+			return auth
+		}
+		p.report(fmt.Errorf("login failed: %w", err))
+
+		select {
+		case <-tc.C:
+		case <-p.doneCh:
+			return nil
+		}
+	}
+}
+
+// SetErrorHandler sets the function receiving errors of background login and token renewal.
+// Errors reported before the handler is set are passed to it at once.
+func (p *Provider) SetErrorHandler(fn func(err error)) {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+
+	p.onError = fn
+	for _, err := range p.pending {
+		fn(err)
+	}
+	p.pending = nil
+}
+
+// report passes the error to the error handler or keeps it until the handler is set.
+func (p *Provider) report(err error) {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+
+	err = fmt.Errorf("vault: %w", err)
+	if p.onError == nil {
+		p.pending = append(p.pending, err)
+		return
+	}
+
+	p.onError(err)
+}
+
+// Close stops background token renewal.
+func (p *Provider) Close() error {
+	p.once.Do(func() {
+		close(p.doneCh)
+	})
+	p.wg.Wait()
+
+	return nil
 }
 
 // Get retrieves a value from vault using the KV engine. The actual key selected is determined by the value
 // separated by the colon. For example "database:password" will retrieve the key "password" from the path
-// "database".
+// "database". Retrieved secrets are cached for CacheTTL.
 func (p *Provider) Get(v string) (string, error) {
 	// <path>/data/<path-secret>:key
 	split := strings.Split(v, ":")
@@ -68,9 +380,12 @@ func (p *Provider) Get(v string) (string, error) {
 	pathSecret := split[0]
 	key := split[1]
 
+	p.mu.RLock()
 	res, ok := p.results[pathSecret]
-	if ok {
-		val, ok := res[key]
+	p.mu.RUnlock()
+
+	if ok && (p.cfg.CacheTTL < 0 || p.now().Before(res.expires)) {
+		val, ok := res.secrets[key]
 		if !ok {
 			return "", errors.New("key not found in cached data")
 		}
@@ -78,18 +393,59 @@ func (p *Provider) Get(v string) (string, error) {
 		return val, nil
 	}
 
-	secret, err := p.client.Read(fmt.Sprintf("%s/data/%s", p.path, pathSecret))
+	secrets, err := p.read(pathSecret)
 	if err != nil {
-		return "", fmt.Errorf("reading: %w", err)
+		return "", err
 	}
 
-	if secret == nil {
-		return "", errors.New("secret not found")
+	p.mu.Lock()
+	p.results[pathSecret] = cacheEntry{
+		secrets: secrets,
+		expires: p.now().Add(p.cfg.CacheTTL),
 	}
+	p.mu.Unlock()
 
-	data, ok := secret.Data["data"].(map[string]interface{})
+	val, ok := secrets[key]
 	if !ok {
-		return "", errors.New("invalid data in secret")
+		return "", errors.New("key not found in retrieved data")
+	}
+
+	return val, nil
+}
+
+// Invalidate removes cached secrets, so the next Get reads them from Vault.
+func (p *Provider) Invalidate() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.results = make(map[string]cacheEntry)
+
+	return nil
+}
+
+// read reads the secret from the KV engine of the configured version.
+func (p *Provider) read(pathSecret string) (map[string]string, error) {
+	path := fmt.Sprintf("%s/data/%s", p.cfg.Path, pathSecret)
+	if p.cfg.KVVersion == 1 {
+		path = fmt.Sprintf("%s/%s", p.cfg.Path, pathSecret)
+	}
+
+	secret, err := p.client.Logical().Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+
+	if secret == nil {
+		return nil, errors.New("secret not found")
+	}
+
+	data := secret.Data
+	if p.cfg.KVVersion == 2 {
+		var ok bool
+		data, ok = secret.Data["data"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("invalid data in secret")
+		}
 	}
 
 	secrets := make(map[string]string)
@@ -97,20 +453,16 @@ func (p *Provider) Get(v string) (string, error) {
 	for k, v := range data {
 		val, ok := v.(string)
 		if !ok {
-			return "", errors.New("secret value in data is not string")
+			return nil, errors.New("secret value in data is not string")
 		}
 
 		secrets[k] = val
 	}
 
-	val, ok := secrets[key]
-	if !ok {
-		return "", errors.New("key not found in retrieved data")
-	}
-
-	p.results[pathSecret] = secrets
-
-	return val, nil
+	return secrets, nil
 }
 
-
+// Name returns provider name for configuration reports.
+func (p *Provider) Name() string {
+	return "vault"
+}
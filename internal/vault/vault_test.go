@@ -0,0 +1,401 @@
+package vault
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testToken = "s.test"
+
+// fakeVault serves the auth, token and KV endpoints used by the provider.
+type fakeVault struct {
+	mu sync.Mutex
+
+	roleID, secretID string
+	k8sRole, jwt     string
+	leaseDuration    int
+	lookupStatus     int
+	renewStatus      int
+	secrets          map[string]map[string]interface{}
+
+	logins   int
+	renewals int
+	reads    int
+}
+
+func newFakeVault(t *testing.T, fv *fakeVault) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(fv.serve))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (fv *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case path == "auth/approle/login":
+		if body["role_id"] != fv.roleID || body["secret_id"] != fv.secretID {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		fv.logins++
+		fv.writeAuth(w)
+
+	case path == "auth/kubernetes/login":
+		if body["role"] != fv.k8sRole || body["jwt"] != fv.jwt {
+			writeError(w, http.StatusForbidden)
+			return
+		}
+		fv.logins++
+		fv.writeAuth(w)
+
+	case path == "auth/token/lookup-self":
+		if fv.lookupStatus != 0 {
+			writeError(w, fv.lookupStatus)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"data": map[string]interface{}{"ttl": fv.leaseDuration, "renewable": true},
+		})
+
+	case path == "auth/token/renew-self":
+		fv.renewals++
+		if fv.renewStatus != 0 {
+			writeError(w, fv.renewStatus)
+			return
+		}
+		fv.writeAuth(w)
+
+	default:
+		if r.Header.Get("X-Vault-Token") != testToken {
+			writeError(w, http.StatusForbidden)
+			return
+		}
+		data, ok := fv.secrets[path]
+		if !ok {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		fv.reads++
+		writeJSON(w, map[string]interface{}{"data": data})
+	}
+}
+
+func (fv *fakeVault) writeAuth(w http.ResponseWriter) {
+	writeJSON(w, map[string]interface{}{
+		"auth": map[string]interface{}{
+			"client_token":   testToken,
+			"renewable":      true,
+			"lease_duration": fv.leaseDuration,
+		},
+	})
+}
+
+func (fv *fakeVault) counts() (logins, renewals, reads int) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+
+	return fv.logins, fv.renewals, fv.reads
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{http.StatusText(status)}})
+}
+
+// eventually waits until cond holds or fails the test after a few seconds.
+func eventually(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// errorLog collects errors passed to the error handler of the provider.
+type errorLog struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *errorLog) handle(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errors = append(l.errors, err.Error())
+}
+
+// has reports whether an error containing text was passed.
+func (l *errorLog) has(text string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, err := range l.errors {
+		if strings.Contains(err, text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func kv2(data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"data": data}
+}
+
+func TestAppRoleLogin(t *testing.T) {
+	fv := &fakeVault{
+		roleID:        "role",
+		secretID:      "secret",
+		leaseDuration: 3600,
+		secrets:       map[string]map[string]interface{}{"secret/data/db": kv2(map[string]interface{}{"password": "pass"})},
+	}
+	server := newFakeVault(t, fv)
+
+	p, err := NewWithConfig(Config{
+		Address:    server.URL,
+		Path:       "secret",
+		AuthMethod: AuthAppRole,
+		RoleID:     "role",
+		SecretID:   "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	defer p.Close()
+
+	got, err := p.Get("db:password")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "pass" {
+		t.Fatalf("Get = %q, want %q", got, "pass")
+	}
+
+	if _, err := NewWithConfig(Config{
+		Address:    server.URL,
+		Path:       "secret",
+		AuthMethod: AuthAppRole,
+		RoleID:     "role",
+		SecretID:   "wrong",
+	}); err == nil {
+		t.Fatal("NewWithConfig with a wrong secret id succeeded")
+	}
+}
+
+func TestKubernetesLogin(t *testing.T) {
+	fv := &fakeVault{
+		k8sRole:       "catalogs",
+		jwt:           "service-account-jwt",
+		leaseDuration: 3600,
+		secrets:       map[string]map[string]interface{}{"secret/data/db": kv2(map[string]interface{}{"user": "catalogs"})},
+	}
+	server := newFakeVault(t, fv)
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(tokenPath, []byte("service-account-jwt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewWithConfig(Config{
+		Address:      server.URL,
+		Path:         "secret",
+		AuthMethod:   AuthKubernetes,
+		K8sRole:      "catalogs",
+		K8sTokenPath: tokenPath,
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	defer p.Close()
+
+	got, err := p.Get("db:user")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "catalogs" {
+		t.Fatalf("Get = %q, want %q", got, "catalogs")
+	}
+
+	if _, err := NewWithConfig(Config{
+		Address:      server.URL,
+		AuthMethod:   AuthKubernetes,
+		K8sRole:      "catalogs",
+		K8sTokenPath: filepath.Join(t.TempDir(), "missing"),
+	}); err == nil {
+		t.Fatal("NewWithConfig without a service account token succeeded")
+	}
+}
+
+func TestTokenRenewal(t *testing.T) {
+	fv := &fakeVault{leaseDuration: 3600}
+	server := newFakeVault(t, fv)
+
+	p, err := NewWithConfig(Config{Address: server.URL, Path: "secret", Token: testToken})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+
+	eventually(t, func() bool {
+		_, renewals, _ := fv.counts()
+		return renewals > 0
+	}, "token was not renewed")
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestTokenLookupErrors(t *testing.T) {
+	fv := &fakeVault{lookupStatus: http.StatusForbidden}
+	server := newFakeVault(t, fv)
+
+	// A token without the lookup-self capability is used as is and not renewed
+	p, err := NewWithConfig(Config{Address: server.URL, Path: "secret", Token: testToken})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	_ = p.Close()
+
+	if _, renewals, _ := fv.counts(); renewals != 0 {
+		t.Fatalf("renewals = %d, want 0", renewals)
+	}
+
+	// The error reported before the handler is set is passed to it
+	var errs errorLog
+	p.SetErrorHandler(errs.handle)
+	if !errs.has("vault: can't look up token, renewal disabled") {
+		t.Fatalf("reported errors = %q, want the lookup error", errs.errors)
+	}
+
+	fv.mu.Lock()
+	fv.lookupStatus = http.StatusBadRequest
+	fv.mu.Unlock()
+
+	if _, err := NewWithConfig(Config{Address: server.URL, Path: "secret", Token: testToken}); err == nil {
+		t.Fatal("NewWithConfig succeeded when token lookup failed")
+	}
+}
+
+func TestReloginWhenRenewalFails(t *testing.T) {
+	fv := &fakeVault{
+		roleID:        "role",
+		secretID:      "secret",
+		leaseDuration: 1,
+		renewStatus:   http.StatusForbidden,
+	}
+	server := newFakeVault(t, fv)
+
+	p, err := NewWithConfig(Config{
+		Address:    server.URL,
+		AuthMethod: AuthAppRole,
+		RoleID:     "role",
+		SecretID:   "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+
+	var errs errorLog
+	p.SetErrorHandler(errs.handle)
+
+	eventually(t, func() bool {
+		logins, _, _ := fv.counts()
+		return logins > 1
+	}, "provider did not log in again after renewal failed")
+	eventually(t, func() bool {
+		return errs.has("vault: token renewal stopped")
+	}, "stopped renewal was not reported")
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	fv := &fakeVault{
+		lookupStatus: http.StatusForbidden,
+		secrets:      map[string]map[string]interface{}{"kv/db": {"password": "pass"}},
+	}
+	server := newFakeVault(t, fv)
+
+	p, err := NewWithConfig(Config{
+		Address:   server.URL,
+		Path:      "kv",
+		KVVersion: 1,
+		Token:     testToken,
+		CacheTTL:  time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	defer p.Close()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	get := func(wantReads int) {
+		t.Helper()
+
+		got, err := p.Get("db:password")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got != "pass" {
+			t.Fatalf("Get = %q, want %q", got, "pass")
+		}
+		if _, _, reads := fv.counts(); reads != wantReads {
+			t.Fatalf("reads = %d, want %d", reads, wantReads)
+		}
+	}
+
+	get(1)
+	get(1)
+
+	now = now.Add(30 * time.Second)
+	get(1)
+
+	now = now.Add(time.Minute)
+	get(2)
+
+	p.Invalidate()
+	get(3)
+
+	if _, err := p.Get("db:missing"); err == nil {
+		t.Fatal("Get of a missing key succeeded")
+	}
+}
+
+func TestMain(m *testing.M) {
+	// The client must not pick up a token or an address of the environment
+	os.Unsetenv("VAULT_TOKEN")
+	os.Unsetenv("VAULT_ADDR")
+
+	os.Exit(m.Run())
+}
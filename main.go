@@ -2,18 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
 	"github.com/gin-gonic/gin"
 	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/rusrafkasimov/catalogs/docs"
 	"github.com/rusrafkasimov/catalogs/internal/config"
 	"github.com/rusrafkasimov/catalogs/internal/logger"
 	"github.com/rusrafkasimov/catalogs/internal/mongo"
 	"github.com/rusrafkasimov/catalogs/internal/queue"
+	"github.com/rusrafkasimov/catalogs/internal/replicator"
+	"github.com/rusrafkasimov/catalogs/internal/secrets"
 	"github.com/rusrafkasimov/catalogs/internal/trace"
-	"github.com/rusrafkasimov/catalogs/internal/vault"
 	"github.com/rusrafkasimov/catalogs/pkg/delivery/router"
-	"time"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 // @title Swagger Catalogs Service
@@ -22,7 +32,7 @@ import (
 
 // @contact.name Ruslan Kasimov
 
-// @host 127.0.0.1:8091
+// @host 127.0.0.1:8090
 // @BasePath /
 
 // @securityDefinitions.apikey TokenJWT
@@ -32,7 +42,6 @@ import (
 const (
 	Name           = "Catalogs"
 	contextKeyName = "Name"
-	serverTimeout  = 10 * time.Second
 )
 
 func main() {
@@ -50,6 +59,10 @@ func main() {
 		Name+"_"+id,
 	)
 
+	// Stop the service on SIGINT or SIGTERM
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	var env string
 
 	flag.StringVar(&env, "env", ".env.local", "Environment Variables filename")
@@ -60,60 +73,165 @@ func main() {
 		fmt.Printf("Error: can't load env. %" + err.Error())
 	}
 
-	// Initialize vault
-	vaultProvider := vault.NewVaultProvider()
-	appConfig := config.NewConfig(vaultProvider)
+	// Initialize secret provider
+	secretProvider, err := secrets.NewProvider()
+	if err != nil {
+		fmt.Println("Error: can't init secret provider. " + err.Error())
+		os.Exit(1)
+	}
+
+	configuration := config.NewConfig(secretProvider)
+	cfg, err := config.Load(configuration)
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
 
 	// Initialize logger
-	loki, err := logger.NewLogger(Name, "api", appConfig)
+	loki, err := logger.NewReloadableLogger(Name, "api", cfg.Loki)
 	if err != nil {
 		fmt.Println("Error while connect to loki")
+		os.Exit(1)
+	}
+
+	if reporter, ok := secretProvider.(config.ErrorReporter); ok {
+		reporter.SetErrorHandler(func(err error) {
+			loki.Errorf("Error: secret provider. %s", err.Error())
+		})
+	}
+
+	for _, line := range cfg.ReportLines() {
+		loki.Infof("Config: %s", line)
 	}
 
 	// Initialize tracing
-	closer, err := trace.InitJaegerTracing(ctx, contextKeyName, appConfig)
+	closer, err := trace.InitJaegerTracing(ctx, contextKeyName, cfg.Jaeger)
 	if err != nil {
-		loki.Errorf("Error while init tracing")
+		loki.Errorf("Error while init tracing. %s", err.Error())
 	}
-	defer closer.Close()
 
 	// Initialize Database
-	mgoDB, err := mongo.InitDatabase(ctx, loki, appConfig)
+	mgoDB, err := mongo.InitDatabase(ctx, loki, cfg.Mongo)
 	if err != nil {
-		loki.Errorf("Error init database")
+		fatal(loki, "Error init database. %s", err.Error())
 	}
 
-	// Initialize NATS Queue
-	newQueue, err := queue.NewQueue(ctx, loki, appConfig)
+	// Initialize event queue
+	newQueue, err := queue.New(ctx, loki, cfg.Queue)
 	if err != nil {
-		loki.Errorf("Error init new queue")
+		fatal(loki, "Error init new queue. %s", err.Error())
+	}
+
+	// Reload configuration on schedule and on SIGHUP
+	watcher := config.NewWatcher(configuration, env, cfg, cfg.Reload.Interval)
+	watcher.Subscribe(reloadSubscriber(loki, mgoDB, newQueue))
+	go watcher.Run(sigCtx, func(err error) {
+		loki.Errorf("Error: can't reload config. %s", err.Error())
+	})
+
+	resources := shutdownResources{
+		queue:   newQueue,
+		mongo:   mgoDB,
+		tracer:  closer,
+		secrets: secretProvider,
 	}
 
-	// Build context
-	repoCtx := router.BuildRepositoryContext(mgoDB.Client, ctx, newQueue, loki)
+	// Build context. The outbox relay publishes to the queue itself, an operation stays in the outbox
+	// until the queue accepts it.
+	repoCtx := router.BuildRepositoryContext(mgoDB, ctx, newQueue, cfg.Outbox, loki)
 	ucCtx := router.BuildUcaseContext(repoCtx, loki)
-	appCtx := router.BuildApplicationContext(ucCtx, loki)
+
+	// Select replication source: events published by the service or all writes to the collection
+	var replicationSource replicator.Source = newQueue
+	if cfg.Replication.Source == "changestream" {
+		changeStream := repository.NewChangeStream(mgoDB, loki, cfg.Replication.ResumeID)
+		replicationSource = changeStream
+		resources.changeStream = changeStream
+	}
+
+	// Start replication of catalogs into memory storage
+	replicationCtx, stopReplication := context.WithCancel(ctx)
+	replicationDone := make(chan struct{})
+	catalogsReplicator := replicator.New(replicationCtx, repoCtx.CatalogRep, repoCtx.CatalogMem, replicationSource, repoCtx.DeadLettersRep, loki, []models.OperationType{
+		models.OperationTypeCatalogs,
+	}, cfg.Replication)
+	go func() {
+		defer close(replicationDone)
+		if err := catalogsReplicator.Replicate(replicationCtx); err != nil {
+			loki.Errorf("Error: replicator stopped. %s", err.Error())
+		}
+	}()
+
+	// Publish outbox events written together with catalog changes
+	outboxDone := make(chan struct{})
+	go func() {
+		defer close(outboxDone)
+		repoCtx.Outbox.Run(replicationCtx)
+	}()
+
+	appCtx := router.BuildApplicationContext(ucCtx, catalogsReplicator, loki)
+
+	resources.stopReplication = stopReplication
+	resources.replicationDone = replicationDone
+	resources.outboxDone = outboxDone
 
 	// Initialize gin routes and run server
 	rGin := gin.Default()
 	gin.ForceConsoleColor()
 	router.MapUrl(rGin, appCtx)
 
-	httpHost, err := appConfig.Get("HTTP_HOST")
+	httpConfig := cfg.HTTP
+
+	listener, err := net.Listen("tcp", httpConfig.Address())
 	if err != nil {
-		loki.Errorf(err.Error())
+		loki.Errorf("Error: can't listen %s. %s", httpConfig.Address(), err.Error())
+		gracefulShutdown(cfg.Shutdown, loki, resources)
+		return
 	}
 
-	httpPort := ":8090"
+	docs.SwaggerInfo.Host = swaggerHost(httpConfig.Host, listener.Addr())
 
-	if err = rGin.Run(httpPort); err != nil {
-		loki.Errorf("Error: can't start GIN router. %s", err.Error())
+	server := &http.Server{
+		Handler:        rGin,
+		ReadTimeout:    httpConfig.ReadTimeout,
+		WriteTimeout:   httpConfig.WriteTimeout,
+		IdleTimeout:    httpConfig.IdleTimeout,
+		MaxHeaderBytes: httpConfig.MaxHeaderBytes,
 	}
+	resources.server = server
 
-	loki.Infof("Upstream started at %v", httpHost+httpPort)
-
-	defer func(){
-		loki.Infof("Catalogs service stopped")
-		_ = newQueue.Close()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			loki.Errorf("Error: GIN router stopped. %s", err.Error())
+			stop()
+		}
 	}()
+
+	loki.Infof("Upstream started at %v", listener.Addr().String())
+
+	<-sigCtx.Done()
+	stop()
+
+	gracefulShutdown(cfg.Shutdown, loki, resources)
+}
+
+// swaggerHost returns the host shown in Swagger UI for the bound listener address.
+func swaggerHost(host string, addr net.Addr) string {
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// fatal logs the error, flushes the logger and exits.
+func fatal(logger promtail.Client, format string, args ...interface{}) {
+	logger.Errorf(format, args...)
+	logger.Shutdown()
+	os.Exit(1)
 }
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/rusrafkasimov/catalogs/internal/errs"
+	"reflect"
+	"strings"
+)
+
+// bindingError converts the error of request binding to the bad request error listing invalid fields
+// by their names in the request.
+func bindingError(prefix string, obj interface{}, err error) *errs.ApiErr {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return errs.NewBadRequestError(prefix + err.Error())
+	}
+
+	fields := make([]string, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, fmt.Sprintf("%s %s", fieldName(obj, fe), fieldRule(fe)))
+	}
+
+	return errs.NewBadRequestError(prefix + strings.Join(fields, "; "))
+}
+
+// fieldName returns the path of the invalid field by form or JSON names, Go names are used for fields
+// without tags.
+func fieldName(obj interface{}, fe validator.FieldError) string {
+	t := reflect.TypeOf(obj)
+
+	// The namespace starts with the name of the struct type
+	path := strings.Split(fe.StructNamespace(), ".")[1:]
+	names := make([]string, 0, len(path))
+	for _, goName := range path {
+		for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+			t = t.Elem()
+		}
+
+		name := goName
+		if t != nil && t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(strings.Split(goName, "[")[0]); ok {
+				name = tagName(field, goName)
+				t = field.Type
+			} else {
+				t = nil
+			}
+		}
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return fe.Field()
+	}
+
+	return strings.Join(names, ".")
+}
+
+// tagName returns the form or JSON name of the field, keeping the index of goName.
+func tagName(field reflect.StructField, goName string) string {
+	for _, key := range []string{"form", "json"} {
+		if name := strings.Split(field.Tag.Get(key), ",")[0]; name != "" && name != "-" {
+			if i := strings.Index(goName, "["); i >= 0 {
+				return name + goName[i:]
+			}
+			return name
+		}
+	}
+
+	return goName
+}
+
+func fieldRule(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		if param := strings.Fields(fe.Param()); len(param) == 2 {
+			return fmt.Sprintf("is required when %s is %s", strings.ToLower(param[0]), param[1])
+		}
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	default:
+		return "is invalid (" + fe.Tag() + ")"
+	}
+}
@@ -1,13 +1,14 @@
 package controllers
 
 import (
-	"context"
+	"errors"
 	"github.com/afiskon/promtail-client/promtail"
 	"github.com/gin-gonic/gin"
 	"github.com/opentracing/opentracing-go"
 	"github.com/rusrafkasimov/catalogs/internal/errs"
 	"github.com/rusrafkasimov/catalogs/internal/trace"
 	"github.com/rusrafkasimov/catalogs/pkg/dto"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
 	"github.com/rusrafkasimov/catalogs/pkg/usecases"
 	"net/http"
 	"time"
@@ -41,13 +42,14 @@ func NewCatalogsController(uc usecases.CatalogsUseCase, logger promtail.Client)
 // @Param data body dto.CatalogRequest true "Catalog"
 // @Success 200 {object} dto.CatalogRequest
 // @Failure 400 {object} dto.Error Invalid JSON
+// @Failure 422 {object} dto.Error Invalid parent catalog or value violates the category schema
 // @Failure 500 {object} dto.Error Can't create catalog
 // @Router /catalog [post]
 func (cc *CatalogsController) CreateCatalog(c *gin.Context) {
 	tracer := opentracing.GlobalTracer()
 	controllerSpan := tracer.StartSpan("Controller:CreateCatalog")
 	defer controllerSpan.Finish()
-	ctx := context.Background()
+	ctx := localeContext(c)
 
 	catalogDto := &dto.CatalogRequest{}
 	if err := c.ShouldBindJSON(&catalogDto); err != nil {
@@ -59,7 +61,7 @@ func (cc *CatalogsController) CreateCatalog(c *gin.Context) {
 	catalogResponse, err := cc.catalogsUC.CreateCatalog(ctx, catalogDto, controllerSpan)
 	if err != nil {
 		trace.OnError(cc.logger, controllerSpan, err)
-		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+		cc.handleError(c, err)
 		return
 	}
 
@@ -69,32 +71,56 @@ func (cc *CatalogsController) CreateCatalog(c *gin.Context) {
 
 // GetCatalogs godoc
 // @Summary Get catalogs
-// @Description Get JSON CatalogsRequest, return JSON GetCatalogsResponse
+// @Description Return JSON GetCatalogsResponse of catalogs of the category, or of all categories when it is empty.
+// @Description Catalogs are paginated by page and page_size, or by cursor taken from next_cursor of the previous page.
+// @Description Only active catalogs are returned unless active or include_inactive is set.
+// @Description Filters in JSON CatalogsRequest body are deprecated and override query parameters
 // @Tags Catalog
 // @Produce  json
-// @Content application/json
 // @Security TokenJWT
-// @Param data body dto.CatalogsRequest true "Catalogs"
+// @Param category query string false "Category, all categories when empty"
+// @Param query query string false "Part of the catalog name"
+// @Param active query bool false "Return only active or only inactive catalogs"
+// @Param include_inactive query bool false "Return inactive and archived catalogs when active is not set"
+// @Param sort_by query string false "Sort field" Enums(name, created_at, updated_at, value) default(name)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param page query int false "Page number" minimum(1) default(1)
+// @Param page_size query int false "Page size" minimum(1) maximum(1000) default(50)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
 // @Success 200 {object} dto.GetCatalogsResponse
-// @Failure 400 {object} dto.Error Invalid JSON
+// @Failure 400 {object} dto.Error Invalid query or JSON
 // @Failure 500 {object} dto.Error Can't get catalogs
 // @Router /catalog [get]
 func (cc *CatalogsController) GetCatalogs(c *gin.Context) {
 	tracer := opentracing.GlobalTracer()
 	controllerSpan := tracer.StartSpan("Controller:GetCatalogs")
 	defer controllerSpan.Finish()
-	ctx := context.Background()
+	ctx := localeContext(c)
 
 	catalogsDto := &dto.CatalogsRequest{}
-	if err := c.ShouldBindJSON(&catalogsDto); err != nil {
+	if err := c.ShouldBindQuery(catalogsDto); err != nil {
 		trace.OnError(cc.logger, controllerSpan, err)
-		errs.ErrorHandler(c, errs.NewBadRequestError(errInvJSON+err.Error()))
+		errs.ErrorHandler(c, bindingError(errInvQuery, catalogsDto, err))
 		return
 	}
 
+	// Deprecated: filters in JSON body are still accepted and override query parameters
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(catalogsDto); err != nil {
+			trace.OnError(cc.logger, controllerSpan, err)
+			errs.ErrorHandler(c, bindingError(errInvJSON, catalogsDto, err))
+			return
+		}
+	}
+
 	catalogsResponse, err := cc.catalogsUC.GetCatalogs(ctx, catalogsDto, controllerSpan)
 	if err != nil {
 		trace.OnError(cc.logger, controllerSpan, err)
+		if errors.Is(err, usecases.ErrInvalidCatalogsQuery) {
+			errs.ErrorHandler(c, errs.NewBadRequestError(err.Error()))
+			return
+		}
 		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
 		return
 	}
@@ -102,30 +128,159 @@ func (cc *CatalogsController) GetCatalogs(c *gin.Context) {
 	c.JSON(http.StatusOK, catalogsResponse)
 }
 
-// GetCatalogCategories godoc
-// @Summary Get categories
-// @Description Return JSON GetCategoriesResponse
+// SearchCatalogs godoc
+// @Summary Search catalogs
+// @Description Full-text search by name, value and desc across categories. Every word of the query must
+// @Description match a word of the catalog exactly, as a prefix or, when fuzzy is set, with a typo.
+// @Description Return JSON SearchCatalogsResponse, most relevant first
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param q query string true "Search query"
+// @Param category query string false "Category, all categories when empty"
+// @Param active query bool false "Return only active or only inactive catalogs"
+// @Param include_inactive query bool false "Return inactive and archived catalogs when active is not set"
+// @Param fuzzy query bool false "Match words with typos" default(true)
+// @Param limit query int false "Maximum number of results" minimum(1) maximum(100) default(20)
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.SearchCatalogsResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 500 {object} dto.Error Can't search catalogs
+// @Router /catalog/search [get]
+func (cc *CatalogsController) SearchCatalogs(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:SearchCatalogs")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	searchDto := &dto.SearchCatalogsRequest{}
+	if err := c.ShouldBindQuery(searchDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, searchDto, err))
+		return
+	}
+
+	searchResponse, err := cc.catalogsUC.SearchCatalogs(ctx, searchDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, searchResponse)
+}
+
+// SuggestCatalogs godoc
+// @Summary Suggest catalogs
+// @Description Return JSON SuggestCatalogsResponse with active catalogs whose names start with the prefix,
+// @Description in alphabetical order and without repeated names. Letter case is ignored
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param prefix query string true "Beginning of the catalog name"
+// @Param category query string false "Category, all categories when empty"
+// @Param limit query int false "Maximum number of suggestions" minimum(1) maximum(50) default(10)
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.SuggestCatalogsResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 500 {object} dto.Error Can't suggest catalogs
+// @Router /catalog/suggest [get]
+func (cc *CatalogsController) SuggestCatalogs(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:SuggestCatalogs")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	suggestDto := &dto.SuggestCatalogsRequest{}
+	if err := c.ShouldBindQuery(suggestDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, suggestDto, err))
+		return
+	}
+
+	suggestResponse, err := cc.catalogsUC.SuggestCatalogs(ctx, suggestDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestResponse)
+}
+
+// LookupCatalog godoc
+// @Summary Lookup catalog by value
+// @Description Return JSON GetCatalogResponse of the catalog of the category with the value, active catalog is preferred
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param category query string true "Category"
+// @Param value query string true "Catalog value"
+// @Param include_inactive query bool false "Return inactive or archived catalog"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetCatalogResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 404 {object} dto.Error Catalog not found
+// @Router /catalog/lookup [get]
+func (cc *CatalogsController) LookupCatalog(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:LookupCatalog")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	lookupDto := &dto.LookupCatalogRequest{}
+	if err := c.ShouldBindQuery(lookupDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, lookupDto, err))
+		return
+	}
+
+	catalogResponse, err := cc.catalogsUC.LookupCatalog(ctx, lookupDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, catalogResponse)
+}
+
+// GetRecentCatalogs godoc
+// @Summary Get recently changed catalogs
+// @Description Return JSON GetCatalogsResponse of catalogs updated since the time, most recent first.
+// @Description Only active catalogs are returned unless include_inactive is set
 // @Tags Catalog
 // @Produce  json
-// @Content application/json
 // @Security TokenJWT
-// @Success 200 {object} dto.GetCategoriesResponse
-// @Failure 500 {object} dto.Error Can't get categories
-// @Router /categories [get]
-func (cc *CatalogsController) GetCatalogCategories(c *gin.Context) {
+// @Param since query string false "RFC 3339 time"
+// @Param limit query int false "Maximum number of catalogs" minimum(1) maximum(1000) default(50)
+// @Param include_inactive query bool false "Return inactive and archived catalogs"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetCatalogsResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 500 {object} dto.Error Can't get catalogs
+// @Router /catalog/recent [get]
+func (cc *CatalogsController) GetRecentCatalogs(c *gin.Context) {
 	tracer := opentracing.GlobalTracer()
-	controllerSpan := tracer.StartSpan("Controller:GetCatalogCategories")
+	controllerSpan := tracer.StartSpan("Controller:GetRecentCatalogs")
 	defer controllerSpan.Finish()
-	ctx := context.Background()
+	ctx := localeContext(c)
 
-	categoriesResponse, err := cc.catalogsUC.GetCatalogCategories(ctx, controllerSpan)
+	recentDto := &dto.RecentCatalogsRequest{}
+	if err := c.ShouldBindQuery(recentDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, recentDto, err))
+		return
+	}
+
+	catalogsResponse, err := cc.catalogsUC.GetRecentCatalogs(ctx, recentDto, controllerSpan)
 	if err != nil {
 		trace.OnError(cc.logger, controllerSpan, err)
 		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, categoriesResponse)
+	c.JSON(http.StatusOK, catalogsResponse)
 }
 
 // GetCatalogByID godoc
@@ -136,15 +291,18 @@ func (cc *CatalogsController) GetCatalogCategories(c *gin.Context) {
 // @Content application/json
 // @Security TokenJWT
 // @Param id path int true "Catalog ID"
+// @Param include_inactive query bool false "Return inactive or archived catalog"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
 // @Success 200 {object} dto.GetCatalogResponse
 // @Failure 400 {object} dto.Error Invalid ID
+// @Failure 404 {object} dto.Error Catalog not found
 // @Failure 500 {object} dto.Error Can't get catalog
 // @Router /catalog/:id [get]
 func (cc *CatalogsController) GetCatalogByID(c *gin.Context) {
 	tracer := opentracing.GlobalTracer()
 	controllerSpan := tracer.StartSpan("Controller:GetCatalogByID")
 	defer controllerSpan.Finish()
-	ctx := context.Background()
+	ctx := localeContext(c)
 
 	id, ok := c.Params.Get("id")
 	if !ok || id == "" {
@@ -153,10 +311,17 @@ func (cc *CatalogsController) GetCatalogByID(c *gin.Context) {
 		return
 	}
 
-	catalogResponse, err := cc.catalogsUC.GetCatalogByID(ctx, id, controllerSpan)
+	catalogDto := &dto.GetCatalogRequest{}
+	if err := c.ShouldBindQuery(catalogDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, catalogDto, err))
+		return
+	}
+
+	catalogResponse, err := cc.catalogsUC.GetCatalogByID(ctx, id, catalogDto, controllerSpan)
 	if err != nil {
 		trace.OnError(cc.logger, controllerSpan, err)
-		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+		cc.handleError(c, err)
 		return
 	}
 
@@ -173,13 +338,16 @@ func (cc *CatalogsController) GetCatalogByID(c *gin.Context) {
 // @Param data body dto.CatalogRequest true "Catalog"
 // @Success 200 {object} dto.UpdateCatalogResponse
 // @Failure 400 {object} dto.Error Invalid JSON
+// @Failure 404 {object} dto.Error Catalog not found
+// @Failure 409 {object} dto.Error Catalog version conflict or catalog is archived
+// @Failure 422 {object} dto.Error Invalid parent catalog or value violates the category schema
 // @Failure 500 {object} dto.Error Can't update catalog
 // @Router /catalog [put]
 func (cc *CatalogsController) UpdateCatalog(c *gin.Context) {
 	tracer := opentracing.GlobalTracer()
 	controllerSpan := tracer.StartSpan("Controller:UpdateCatalog")
 	defer controllerSpan.Finish()
-	ctx := context.Background()
+	ctx := localeContext(c)
 
 	catalogDto := &dto.CatalogRequest{}
 	if err := c.ShouldBindJSON(&catalogDto); err != nil {
@@ -191,7 +359,7 @@ func (cc *CatalogsController) UpdateCatalog(c *gin.Context) {
 	catalogResponse, err := cc.catalogsUC.UpdateCatalogByID(ctx, catalogDto, controllerSpan)
 	if err != nil {
 		trace.OnError(cc.logger, controllerSpan, err)
-		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+		cc.handleError(c, err)
 		return
 	}
 
@@ -200,21 +368,23 @@ func (cc *CatalogsController) UpdateCatalog(c *gin.Context) {
 
 // DeleteCatalog godoc
 // @Summary Delete catalog
-// @Description Get id from path, return JSON DeleteCatalogResponse
+// @Description Get id from path, deactivate the catalog, return JSON DeleteCatalogResponse
 // @Tags Catalog
 // @Produce  json
 // @Content application/json
 // @Security TokenJWT
 // @Param id path int true "Catalog ID"
 // @Success 200 {object} dto.DeleteCatalogResponse
-// @Failure 400 {object} dto.Error Invalid JSON
+// @Failure 400 {object} dto.Error Invalid ID
+// @Failure 404 {object} dto.Error Catalog not found
+// @Failure 409 {object} dto.Error Catalog is not active
 // @Failure 500 {object} dto.Error Can't delete catalog
 // @Router /catalog/:id [delete]
 func (cc *CatalogsController) DeleteCatalog(c *gin.Context) {
 	tracer := opentracing.GlobalTracer()
 	controllerSpan := tracer.StartSpan("Controller:DeleteCatalog")
 	defer controllerSpan.Finish()
-	ctx := context.Background()
+	ctx := localeContext(c)
 
 	id, ok := c.Params.Get("id")
 	if !ok || id == "" {
@@ -226,9 +396,298 @@ func (cc *CatalogsController) DeleteCatalog(c *gin.Context) {
 	catalogResponse, err := cc.catalogsUC.DeleteCatalogByID(ctx, id, controllerSpan)
 	if err != nil {
 		trace.OnError(cc.logger, controllerSpan, err)
-		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+		cc.handleError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, catalogResponse)
 }
+
+// DeactivateCatalog godoc
+// @Summary Deactivate catalog
+// @Description Hide the active catalog from reads, return JSON UpdateCatalogResponse
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Catalog ID"
+// @Success 200 {object} dto.UpdateCatalogResponse
+// @Failure 404 {object} dto.Error Catalog not found
+// @Failure 409 {object} dto.Error Catalog is archived or changed concurrently
+// @Failure 500 {object} dto.Error Can't deactivate catalog
+// @Router /catalog/{id}/deactivate [post]
+func (cc *CatalogsController) DeactivateCatalog(c *gin.Context) {
+	cc.setCatalogStatus(c, "Controller:DeactivateCatalog", models.StatusInactive)
+}
+
+// ReactivateCatalog godoc
+// @Summary Reactivate catalog
+// @Description Make the inactive or archived catalog active again, return JSON UpdateCatalogResponse
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Catalog ID"
+// @Success 200 {object} dto.UpdateCatalogResponse
+// @Failure 404 {object} dto.Error Catalog not found
+// @Failure 409 {object} dto.Error Catalog changed concurrently
+// @Failure 500 {object} dto.Error Can't reactivate catalog
+// @Router /catalog/{id}/reactivate [post]
+func (cc *CatalogsController) ReactivateCatalog(c *gin.Context) {
+	cc.setCatalogStatus(c, "Controller:ReactivateCatalog", models.StatusActive)
+}
+
+// ArchiveCatalog godoc
+// @Summary Archive catalog
+// @Description Hide the catalog from reads and forbid its updates until it is reactivated, return JSON UpdateCatalogResponse
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Catalog ID"
+// @Success 200 {object} dto.UpdateCatalogResponse
+// @Failure 404 {object} dto.Error Catalog not found
+// @Failure 409 {object} dto.Error Catalog changed concurrently
+// @Failure 500 {object} dto.Error Can't archive catalog
+// @Router /catalog/{id}/archive [post]
+func (cc *CatalogsController) ArchiveCatalog(c *gin.Context) {
+	cc.setCatalogStatus(c, "Controller:ArchiveCatalog", models.StatusArchived)
+}
+
+// PurgeCatalog godoc
+// @Summary Purge catalog
+// @Description Hard-delete the inactive or archived catalog from Mongo and memory storage, return JSON PurgeCatalogResponse
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Catalog ID"
+// @Success 200 {object} dto.PurgeCatalogResponse
+// @Failure 404 {object} dto.Error Catalog not found
+// @Failure 409 {object} dto.Error Catalog is active, has children or changed concurrently
+// @Failure 500 {object} dto.Error Can't purge catalog
+// @Router /catalog/{id}/purge [post]
+func (cc *CatalogsController) PurgeCatalog(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:PurgeCatalog")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	purgeResponse, err := cc.catalogsUC.PurgeCatalogByID(ctx, c.Param("id"), controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, purgeResponse)
+}
+
+// GetSubtree godoc
+// @Summary Get catalog subtree
+// @Description Return JSON GetSubtreeResponse with the catalog and its descendants, children are sorted by name
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Catalog ID"
+// @Param depth query int false "Levels below the catalog, the whole subtree by default" minimum(0) maximum(64)
+// @Param include_inactive query bool false "Return inactive and archived catalogs"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetSubtreeResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 404 {object} dto.Error Catalog not found
+// @Router /catalog/{id}/subtree [get]
+func (cc *CatalogsController) GetSubtree(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:GetSubtree")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	subtreeDto := &dto.SubtreeRequest{}
+	if err := c.ShouldBindQuery(subtreeDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, subtreeDto, err))
+		return
+	}
+
+	subtreeResponse, err := cc.catalogsUC.GetSubtree(ctx, c.Param("id"), subtreeDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subtreeResponse)
+}
+
+// GetAncestors godoc
+// @Summary Get catalog ancestors
+// @Description Return JSON GetAncestorsResponse with ancestors of the catalog from the root to its parent
+// @Description Inactive catalogs are not found and inactive ancestors are skipped unless include_inactive is set
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Catalog ID"
+// @Param include_inactive query bool false "Return inactive and archived catalogs"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetAncestorsResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 404 {object} dto.Error Catalog not found
+// @Router /catalog/{id}/ancestors [get]
+func (cc *CatalogsController) GetAncestors(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:GetAncestors")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	ancestorsDto := &dto.AncestorsRequest{}
+	if err := c.ShouldBindQuery(ancestorsDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, ancestorsDto, err))
+		return
+	}
+
+	ancestorsResponse, err := cc.catalogsUC.GetAncestors(ctx, c.Param("id"), ancestorsDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ancestorsResponse)
+}
+
+// GetBreadcrumbs godoc
+// @Summary Get catalog breadcrumbs
+// @Description Return JSON GetBreadcrumbsResponse with names from the root to the catalog
+// @Description Inactive catalogs are not found and inactive ancestors are skipped unless include_inactive is set
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Catalog ID"
+// @Param include_inactive query bool false "Return inactive and archived catalogs"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetBreadcrumbsResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 404 {object} dto.Error Catalog not found
+// @Router /catalog/{id}/breadcrumbs [get]
+func (cc *CatalogsController) GetBreadcrumbs(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:GetBreadcrumbs")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	ancestorsDto := &dto.AncestorsRequest{}
+	if err := c.ShouldBindQuery(ancestorsDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, ancestorsDto, err))
+		return
+	}
+
+	breadcrumbsResponse, err := cc.catalogsUC.GetBreadcrumbs(ctx, c.Param("id"), ancestorsDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, breadcrumbsResponse)
+}
+
+// ExportTranslations godoc
+// @Summary Export translations
+// @Description Return JSON ExportTranslationsResponse with names, descriptions and translations of catalogs
+// @Tags Catalog
+// @Produce  json
+// @Security TokenJWT
+// @Param category query string false "Category, all categories when empty"
+// @Param include_inactive query bool false "Export inactive and archived catalogs"
+// @Success 200 {object} dto.ExportTranslationsResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Router /catalog/translations [get]
+func (cc *CatalogsController) ExportTranslations(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:ExportTranslations")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	exportDto := &dto.ExportTranslationsRequest{}
+	if err := c.ShouldBindQuery(exportDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, exportDto, err))
+		return
+	}
+
+	exportResponse, err := cc.catalogsUC.ExportTranslations(ctx, exportDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exportResponse)
+}
+
+// ImportTranslations godoc
+// @Summary Import translations
+// @Description Get JSON ImportTranslationsRequest, change translations of the catalogs, return JSON ImportTranslationsResponse.
+// @Description Items are imported one by one, failed items are listed in the response
+// @Tags Catalog
+// @Produce  json
+// @Content application/json
+// @Security TokenJWT
+// @Param data body dto.ImportTranslationsRequest true "Translations"
+// @Success 200 {object} dto.ImportTranslationsResponse
+// @Failure 400 {object} dto.Error Invalid JSON
+// @Router /catalog/translations [put]
+func (cc *CatalogsController) ImportTranslations(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:ImportTranslations")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	importDto := &dto.ImportTranslationsRequest{}
+	if err := c.ShouldBindJSON(importDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvJSON, importDto, err))
+		return
+	}
+
+	importResponse, err := cc.catalogsUC.ImportTranslations(ctx, importDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, importResponse)
+}
+
+// setCatalogStatus moves the catalog from the path to the lifecycle state.
+func (cc *CatalogsController) setCatalogStatus(c *gin.Context, operation string, status models.CatalogStatus) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan(operation)
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	catalogResponse, err := cc.catalogsUC.SetCatalogStatus(ctx, c.Param("id"), status, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, catalogResponse)
+}
+
+// handleError writes the API error matching the use case error.
+func (cc *CatalogsController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrCatalogNotFound):
+		errs.ErrorHandler(c, errs.NewNotFoundError(err.Error()))
+	case errors.Is(err, usecases.ErrVersionConflict), errors.Is(err, usecases.ErrInvalidTransition),
+		errors.Is(err, usecases.ErrHasChildren):
+		errs.ErrorHandler(c, errs.NewConflictError(err.Error()))
+	case errors.Is(err, usecases.ErrOrphan), errors.Is(err, usecases.ErrCycle), errors.Is(err, usecases.ErrParentCategory),
+		errors.Is(err, usecases.ErrSchemaViolation):
+		errs.ErrorHandler(c, errs.NewUnprocessableEntityError(err.Error()))
+	default:
+		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+	}
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/errs"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/dto"
+	"github.com/rusrafkasimov/catalogs/pkg/usecases"
+	"net/http"
+)
+
+type CategoriesController struct {
+	logger       promtail.Client
+	categoriesUC usecases.CategoriesUseCase
+}
+
+func NewCategoriesController(uc usecases.CategoriesUseCase, logger promtail.Client) *CategoriesController {
+	return &CategoriesController{
+		logger:       logger,
+		categoriesUC: uc,
+	}
+}
+
+// GetCategories godoc
+// @Summary Get categories
+// @Description Return JSON GetCategoriesResponse ordered by sort order and name. Categories which only have catalogs
+// @Description are returned with the name and managed set to false
+// @Tags Category
+// @Produce  json
+// @Security TokenJWT
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetCategoriesResponse
+// @Failure 500 {object} dto.Error Can't get categories
+// @Router /categories [get]
+func (cc *CategoriesController) GetCategories(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:GetCategories")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	categoriesResponse, err := cc.categoriesUC.GetCategories(ctx, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, categoriesResponse)
+}
+
+// GetCategory godoc
+// @Summary Get category
+// @Description Get category name in path, return JSON GetCategoryResponse
+// @Tags Category
+// @Produce  json
+// @Security TokenJWT
+// @Param category path string true "Category"
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetCategoryResponse
+// @Failure 404 {object} dto.Error Category not found
+// @Failure 500 {object} dto.Error Can't get category
+// @Router /categories/{category} [get]
+func (cc *CategoriesController) GetCategory(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:GetCategory")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	categoryResponse, err := cc.categoriesUC.GetCategory(ctx, c.Param("category"), controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, categoryResponse)
+}
+
+// CreateCategory godoc
+// @Summary Create category
+// @Description Get JSON CategoryRequest, return JSON CreateCategoryResponse. Catalogs of the category must match the schema
+// @Tags Category
+// @Produce  json
+// @Content application/json
+// @Security TokenJWT
+// @Param data body dto.CategoryRequest true "Category"
+// @Success 201 {object} dto.CreateCategoryResponse
+// @Failure 400 {object} dto.Error Invalid JSON or schema
+// @Failure 409 {object} dto.Error Category already exists
+// @Failure 422 {object} dto.Error Invalid parent category or catalogs violate the schema
+// @Failure 500 {object} dto.Error Can't create category
+// @Router /categories [post]
+func (cc *CategoriesController) CreateCategory(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:CreateCategory")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	categoryDto := &dto.CategoryRequest{}
+	if err := c.ShouldBindJSON(categoryDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvJSON, categoryDto, err))
+		return
+	}
+
+	categoryResponse, err := cc.categoriesUC.CreateCategory(ctx, categoryDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, categoryResponse)
+}
+
+// UpdateCategory godoc
+// @Summary Update category
+// @Description Get category name in path and JSON CategoryRequest, replace metadata of the category, return JSON UpdateCategoryResponse.
+// @Description Catalogs of the category must match the new schema
+// @Tags Category
+// @Produce  json
+// @Content application/json
+// @Security TokenJWT
+// @Param category path string true "Category"
+// @Param data body dto.CategoryRequest true "Category"
+// @Success 200 {object} dto.UpdateCategoryResponse
+// @Failure 400 {object} dto.Error Invalid JSON or schema
+// @Failure 404 {object} dto.Error Category not found
+// @Failure 422 {object} dto.Error Invalid parent category or catalogs violate the schema
+// @Failure 500 {object} dto.Error Can't update category
+// @Router /categories/{category} [put]
+func (cc *CategoriesController) UpdateCategory(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:UpdateCategory")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	categoryDto := &dto.CategoryRequest{}
+	if err := c.ShouldBindJSON(categoryDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvJSON, categoryDto, err))
+		return
+	}
+
+	categoryResponse, err := cc.categoriesUC.UpdateCategory(ctx, c.Param("category"), categoryDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, categoryResponse)
+}
+
+// DeleteCategory godoc
+// @Summary Delete category
+// @Description Get category name in path, delete metadata of the category without catalogs and child categories
+// @Tags Category
+// @Produce  json
+// @Security TokenJWT
+// @Param category path string true "Category"
+// @Success 200 {object} dto.DeleteCategoryResponse
+// @Failure 404 {object} dto.Error Category not found
+// @Failure 409 {object} dto.Error Category has catalogs or child categories
+// @Failure 500 {object} dto.Error Can't delete category
+// @Router /categories/{category} [delete]
+func (cc *CategoriesController) DeleteCategory(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:DeleteCategory")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	categoryResponse, err := cc.categoriesUC.DeleteCategory(ctx, c.Param("category"), controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, categoryResponse)
+}
+
+// GetCategoryTree godoc
+// @Summary Get category tree
+// @Description Return JSON GetCategoryTreeResponse with root categories and their children sorted by name
+// @Tags Category
+// @Produce  json
+// @Security TokenJWT
+// @Param Accept-Language header string false "Locales of names and descriptions: ru, en, kk" default(ru)
+// @Success 200 {object} dto.GetCategoryTreeResponse
+// @Failure 500 {object} dto.Error Can't get categories
+// @Router /categories/tree [get]
+func (cc *CategoriesController) GetCategoryTree(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:GetCategoryTree")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	treeResponse, err := cc.categoriesUC.GetCategoryTree(ctx, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, treeResponse)
+}
+
+// SetCategoryParent godoc
+// @Summary Set parent category
+// @Description Get JSON CategoryParentRequest, return JSON UpdateCategoryResponse. Empty parent makes the category a root
+// @Tags Category
+// @Produce  json
+// @Content application/json
+// @Security TokenJWT
+// @Param category path string true "Category"
+// @Param data body dto.CategoryParentRequest true "Parent category"
+// @Success 200 {object} dto.UpdateCategoryResponse
+// @Failure 400 {object} dto.Error Invalid JSON
+// @Failure 422 {object} dto.Error Parent category not found or makes a cycle
+// @Failure 500 {object} dto.Error Can't update category
+// @Router /categories/{category}/parent [put]
+func (cc *CategoriesController) SetCategoryParent(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:SetCategoryParent")
+	defer controllerSpan.Finish()
+	ctx := localeContext(c)
+
+	parentDto := &dto.CategoryParentRequest{}
+	if err := c.ShouldBindJSON(parentDto); err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, errs.NewBadRequestError(errInvJSON+err.Error()))
+		return
+	}
+
+	categoryResponse, err := cc.categoriesUC.SetCategoryParent(ctx, c.Param("category"), parentDto, controllerSpan)
+	if err != nil {
+		trace.OnError(cc.logger, controllerSpan, err)
+		cc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, categoryResponse)
+}
+
+// handleError maps errors of the use case to API errors.
+func (cc *CategoriesController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrCategoryNotFound):
+		errs.ErrorHandler(c, errs.NewNotFoundError(err.Error()))
+	case errors.Is(err, usecases.ErrCategoryExists), errors.Is(err, usecases.ErrCategoryInUse):
+		errs.ErrorHandler(c, errs.NewConflictError(err.Error()))
+	case errors.Is(err, usecases.ErrInvalidCategory), errors.Is(err, usecases.ErrInvalidSchema):
+		errs.ErrorHandler(c, errs.NewBadRequestError(err.Error()))
+	case errors.Is(err, usecases.ErrOrphan), errors.Is(err, usecases.ErrCycle), errors.Is(err, usecases.ErrSchemaViolation):
+		errs.ErrorHandler(c, errs.NewUnprocessableEntityError(err.Error()))
+	default:
+		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/errs"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/dto"
+	"github.com/rusrafkasimov/catalogs/pkg/usecases"
+	"net/http"
+)
+
+const errInvQuery = "invalid query parameters: "
+
+type DeadLettersController struct {
+	logger        promtail.Client
+	deadLettersUC usecases.DeadLettersUseCase
+}
+
+func NewDeadLettersController(uc usecases.DeadLettersUseCase, logger promtail.Client) *DeadLettersController {
+	return &DeadLettersController{
+		logger:        logger,
+		deadLettersUC: uc,
+	}
+}
+
+// GetDeadLetters godoc
+// @Summary Get dead letters
+// @Description Return replication events which failed to decode or apply, newest first
+// @Tags Replication
+// @Produce  json
+// @Security TokenJWT
+// @Param limit query int false "Page size, 50 by default"
+// @Param offset query int false "Number of skipped dead letters"
+// @Success 200 {object} dto.GetDeadLettersResponse
+// @Failure 400 {object} dto.Error Invalid query
+// @Failure 500 {object} dto.Error Can't get dead letters
+// @Router /replication/dead-letters [get]
+func (dc *DeadLettersController) GetDeadLetters(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:GetDeadLetters")
+	defer controllerSpan.Finish()
+	ctx := context.Background()
+
+	request := &dto.DeadLettersRequest{}
+	if err := c.ShouldBindQuery(request); err != nil {
+		trace.OnError(dc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, bindingError(errInvQuery, request, err))
+		return
+	}
+
+	response, err := dc.deadLettersUC.GetDeadLetters(ctx, request, controllerSpan)
+	if err != nil {
+		trace.OnError(dc.logger, controllerSpan, err)
+		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReplayDeadLetter godoc
+// @Summary Replay dead letter
+// @Description Publish the operation of the dead letter to the event queue again and remove the dead letter
+// @Tags Replication
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} dto.DeadLetterActionResponse
+// @Failure 404 {object} dto.Error Dead letter not found
+// @Failure 422 {object} dto.Error Dead letter has no operation
+// @Failure 500 {object} dto.Error Can't replay dead letter
+// @Router /replication/dead-letters/{id}/replay [post]
+func (dc *DeadLettersController) ReplayDeadLetter(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:ReplayDeadLetter")
+	defer controllerSpan.Finish()
+	ctx := context.Background()
+
+	response, err := dc.deadLettersUC.ReplayDeadLetter(ctx, c.Param("id"), controllerSpan)
+	if err != nil {
+		trace.OnError(dc.logger, controllerSpan, err)
+		dc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DiscardDeadLetter godoc
+// @Summary Discard dead letter
+// @Description Remove the dead letter without applying it
+// @Tags Replication
+// @Produce  json
+// @Security TokenJWT
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} dto.DeadLetterActionResponse
+// @Failure 404 {object} dto.Error Dead letter not found
+// @Failure 500 {object} dto.Error Can't discard dead letter
+// @Router /replication/dead-letters/{id} [delete]
+func (dc *DeadLettersController) DiscardDeadLetter(c *gin.Context) {
+	tracer := opentracing.GlobalTracer()
+	controllerSpan := tracer.StartSpan("Controller:DiscardDeadLetter")
+	defer controllerSpan.Finish()
+	ctx := context.Background()
+
+	response, err := dc.deadLettersUC.DiscardDeadLetter(ctx, c.Param("id"), controllerSpan)
+	if err != nil {
+		trace.OnError(dc.logger, controllerSpan, err)
+		dc.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// handleError writes the API error matching the use case error.
+func (dc *DeadLettersController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrDeadLetterNotFound):
+		errs.ErrorHandler(c, errs.NewNotFoundError(err.Error()))
+	case errors.Is(err, usecases.ErrNotReplayable):
+		errs.ErrorHandler(c, errs.NewUnprocessableEntityError(err.Error()))
+	default:
+		errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+	}
+}
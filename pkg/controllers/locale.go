@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/rusrafkasimov/catalogs/internal/locale"
+)
+
+// localeContext returns the context carrying the locale negotiated from Accept-Language
+// and reports the locale in Content-Language.
+func localeContext(c *gin.Context) context.Context {
+	loc := locale.Negotiate(c.GetHeader("Accept-Language"))
+
+	c.Header("Content-Language", loc)
+	c.Header("Vary", "Accept-Language")
+
+	return locale.NewContext(context.Background(), loc)
+}
@@ -3,43 +3,82 @@ package router
 import (
 	"context"
 	"github.com/afiskon/promtail-client/promtail"
+	"github.com/rusrafkasimov/catalogs/internal/config"
 	"github.com/rusrafkasimov/catalogs/internal/queue"
+	"github.com/rusrafkasimov/catalogs/internal/replicator"
 	"github.com/rusrafkasimov/catalogs/pkg/controllers"
 	"github.com/rusrafkasimov/catalogs/pkg/repository/memstore"
 	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
 	"github.com/rusrafkasimov/catalogs/pkg/usecases"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type RepositoryContext struct {
 	CatalogRep *repository.CatalogsRepo
 	CatalogMem memstore.MemStore
+	Outbox     *repository.OutboxRelay
+
+	DeadLettersRep *repository.DeadLettersRepo
+	CategoriesRep  *repository.CategoriesRepo
 }
 
 type UseCaseContext struct {
-	catUseCases *usecases.CatalogsUC
+	catUseCases        *usecases.CatalogsUC
+	deadLettersUseCase *usecases.DeadLettersUC
+	categoriesUseCase  *usecases.CategoriesUC
+}
+
+// ReadinessChecker reports whether the service is ready to serve read requests.
+type ReadinessChecker interface {
+	Ready() bool
+}
+
+// ReplicationStatus reports the state of memory storage replication.
+type ReplicationStatus interface {
+	ReadinessChecker
+	StaleOperations() uint64
+	LastSequence(id string) (uint64, bool)
+	Reconcile(ctx context.Context) (*replicator.ReconcileReport, error)
+	LastReconcile() *replicator.ReconcileReport
+	DriftedCategories() uint64
+	DeadLettered() uint64
 }
 
 type ApplicationContext struct {
-	CatalogsController *controllers.CatalogsController
+	CatalogsController    *controllers.CatalogsController
+	DeadLettersController *controllers.DeadLettersController
+	CategoriesController  *controllers.CategoriesController
+	Readiness             ReadinessChecker
+	Replication           ReplicationStatus
 }
 
-func BuildRepositoryContext(mgo *mongo.Client, ctx context.Context, eq queue.EventQueue, logger promtail.Client) *RepositoryContext {
+func BuildRepositoryContext(mgo repository.ClientProvider, ctx context.Context, eq queue.EventQueue, outboxCfg config.Outbox, logger promtail.Client) *RepositoryContext {
+	outbox := repository.NewOutboxRelay(mgo, eq, logger, outboxCfg, ctx.Value("Name").(string))
+
 	return &RepositoryContext{
-		CatalogRep: repository.NewCatalogsRepository(mgo, eq, logger),
+		CatalogRep: repository.NewCatalogsRepository(mgo, outbox, logger),
 		CatalogMem: memstore.NewMemStore(ctx),
+		Outbox:     outbox,
+
+		DeadLettersRep: repository.NewDeadLettersRepository(mgo, outbox, logger),
+		CategoriesRep:  repository.NewCategoriesRepository(mgo, logger),
 	}
 }
 
 func BuildUcaseContext(repoCtx *RepositoryContext, logger promtail.Client) *UseCaseContext {
 	return &UseCaseContext{
-		catUseCases: usecases.NewCatalogsUseCases(repoCtx.CatalogRep, repoCtx.CatalogMem, logger),
+		catUseCases:        usecases.NewCatalogsUseCases(repoCtx.CatalogRep, repoCtx.CatalogMem, logger),
+		deadLettersUseCase: usecases.NewDeadLettersUseCases(repoCtx.DeadLettersRep, logger),
+		categoriesUseCase:  usecases.NewCategoriesUseCases(repoCtx.CategoriesRep, repoCtx.CatalogMem, logger),
 	}
 }
 
-func BuildApplicationContext(ucCtx *UseCaseContext, logger promtail.Client) *ApplicationContext {
+func BuildApplicationContext(ucCtx *UseCaseContext, replication ReplicationStatus, logger promtail.Client) *ApplicationContext {
 	return &ApplicationContext{
-		CatalogsController: controllers.NewCatalogsController(ucCtx.catUseCases, logger),
+		CatalogsController:    controllers.NewCatalogsController(ucCtx.catUseCases, logger),
+		DeadLettersController: controllers.NewDeadLettersController(ucCtx.deadLettersUseCase, logger),
+		CategoriesController:  controllers.NewCategoriesController(ucCtx.categoriesUseCase, logger),
+		Readiness:             replication,
+		Replication:           replication,
 	}
 }
 
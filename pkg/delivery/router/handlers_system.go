@@ -3,10 +3,18 @@ package router
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/errs"
+	"github.com/rusrafkasimov/catalogs/internal/replicator"
+	"github.com/rusrafkasimov/catalogs/pkg/dto"
 	"github.com/uber/jaeger-client-go"
 	"net/http"
 )
 
+const (
+	errNotReady      = "catalogs are not loaded yet"
+	errNotReplicated = "catalog is not replicated"
+)
+
 // PingHandler Return pong
 // @Summary Ping
 // @Tags testing
@@ -29,7 +37,7 @@ func PingHandler(c *gin.Context) {
 // HealthHandler Return Status
 // @Summary Health check
 // @Tags system
-// @Description Return answer from server for checking what server is stay alive
+// @Description Return answer from server for checking what server is stay alive (liveness only)
 // @ID health-check
 // @Produce json
 // @Success 200 {string} string "OK"
@@ -37,4 +45,130 @@ func PingHandler(c *gin.Context) {
 // @Router /health [get]
 func HealthHandler (c *gin.Context) {
 	c.JSON(http.StatusOK, "OK")
-}
\ No newline at end of file
+}
+
+// ReadyHandler Return readiness status
+// @Summary Readiness check
+// @Tags system
+// @Description Return OK when catalogs are loaded into memory storage and replication is running
+// @ID ready-check
+// @Produce json
+// @Success 200 {string} string "OK"
+// @Failure 503 {object} dto.Error Not ready
+// @Router /ready [get]
+func ReadyHandler(checker ReadinessChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if checker == nil || !checker.Ready() {
+			errs.ErrorHandler(c, errs.NewServiceUnavailableError(errNotReady))
+			return
+		}
+
+		c.JSON(http.StatusOK, "OK")
+	}
+}
+
+// ReplicationHandler Return replication status
+// @Summary Replication status
+// @Tags system
+// @Description Return readiness, the number of stale replication operations skipped by the replicator
+// @Description, drift found by the reconciler and the number of events moved to dead letters
+// @ID replication-status
+// @Produce json
+// @Success 200 {object} dto.ReplicationResponse
+// @Router /replication [get]
+func ReplicationHandler(status ReplicationStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var result dto.ReplicationResponse
+
+		result.Payload.Ready = status.Ready()
+		result.Payload.StaleOperations = status.StaleOperations()
+		result.Payload.DriftedCategories = status.DriftedCategories()
+		result.Payload.DeadLettered = status.DeadLettered()
+		if report := status.LastReconcile(); report != nil {
+			converted := convertReconcileReport(report)
+			result.Payload.LastReconcile = &converted
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// ReplicationCatalogHandler Return replication status of catalog
+// @Summary Catalog replication status
+// @Tags system
+// @Description Return the sequence of the last replication event applied to the catalog
+// @ID replication-catalog-status
+// @Produce json
+// @Param id path string true "Catalog ID"
+// @Success 200 {object} dto.ReplicationCatalogResponse
+// @Failure 404 {object} dto.Error Catalog is not replicated
+// @Router /replication/{id} [get]
+func ReplicationCatalogHandler(status ReplicationStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		seq, ok := status.LastSequence(id)
+		if !ok {
+			errs.ErrorHandler(c, errs.NewNotFoundError(errNotReplicated))
+			return
+		}
+
+		var result dto.ReplicationCatalogResponse
+		result.Payload.ID = id
+		result.Payload.LastSequence = seq
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// ResyncHandler Force resync of memory storage
+// @Summary Force resync
+// @Tags system
+// @Description Compare memory storage with Mongo now and reload drifted categories
+// @ID replication-resync
+// @Produce json
+// @Security TokenJWT
+// @Success 200 {object} dto.ResyncResponse
+// @Failure 500 {object} dto.Error Can't resync
+// @Failure 503 {object} dto.Error Not ready
+// @Router /replication/resync [post]
+func ResyncHandler(status ReplicationStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !status.Ready() {
+			errs.ErrorHandler(c, errs.NewServiceUnavailableError(errNotReady))
+			return
+		}
+
+		report, err := status.Reconcile(c.Request.Context())
+		if err != nil {
+			errs.ErrorHandler(c, errs.NewInternalServerError(err.Error()))
+			return
+		}
+
+		var result dto.ResyncResponse
+		result.Payload = convertReconcileReport(report)
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// convertReconcileReport converts the reconciler report to its response.
+func convertReconcileReport(report *replicator.ReconcileReport) dto.ReconcileReport {
+	res := dto.ReconcileReport{
+		StartedAt:  report.StartedAt,
+		DurationMs: report.Duration.Milliseconds(),
+		Categories: report.Categories,
+		Drifted:    make([]dto.CategoryDrift, 0, len(report.Drifted)),
+	}
+
+	for _, drift := range report.Drifted {
+		res.Drifted = append(res.Drifted, dto.CategoryDrift{
+			Category: drift.Category,
+			Missing:  drift.Missing,
+			Extra:    drift.Extra,
+			Changed:  drift.Changed,
+		})
+	}
+
+	return res
+}
@@ -17,17 +17,43 @@ func MapUrl(router *gin.Engine, appCtx *ApplicationContext) {
 	authorized.OPTIONS("/catalog", appCtx.CatalogsController.CreateCatalog)
 	authorized.POST("/catalog", appCtx.CatalogsController.CreateCatalog)
 	authorized.GET("/catalog", appCtx.CatalogsController.GetCatalogs)
+	authorized.GET("/catalog/search", appCtx.CatalogsController.SearchCatalogs)
+	authorized.GET("/catalog/suggest", appCtx.CatalogsController.SuggestCatalogs)
+	authorized.GET("/catalog/lookup", appCtx.CatalogsController.LookupCatalog)
+	authorized.GET("/catalog/recent", appCtx.CatalogsController.GetRecentCatalogs)
+	authorized.GET("/catalog/translations", appCtx.CatalogsController.ExportTranslations)
+	authorized.PUT("/catalog/translations", appCtx.CatalogsController.ImportTranslations)
 	authorized.PUT("/catalog", appCtx.CatalogsController.UpdateCatalog)
 	authorized.OPTIONS("/catalog/:id", appCtx.CatalogsController.GetCatalogByID)
 	authorized.GET("/catalog/:id", appCtx.CatalogsController.GetCatalogByID)
 	authorized.DELETE("/catalog/:id", appCtx.CatalogsController.DeleteCatalog)
-	authorized.OPTIONS("/categories", appCtx.CatalogsController.GetCatalogCategories)
-	authorized.GET("/categories", appCtx.CatalogsController.GetCatalogCategories)
+	authorized.POST("/catalog/:id/deactivate", appCtx.CatalogsController.DeactivateCatalog)
+	authorized.POST("/catalog/:id/reactivate", appCtx.CatalogsController.ReactivateCatalog)
+	authorized.POST("/catalog/:id/archive", appCtx.CatalogsController.ArchiveCatalog)
+	authorized.POST("/catalog/:id/purge", appCtx.CatalogsController.PurgeCatalog)
+	authorized.GET("/catalog/:id/subtree", appCtx.CatalogsController.GetSubtree)
+	authorized.GET("/catalog/:id/ancestors", appCtx.CatalogsController.GetAncestors)
+	authorized.GET("/catalog/:id/breadcrumbs", appCtx.CatalogsController.GetBreadcrumbs)
+	authorized.OPTIONS("/categories", appCtx.CategoriesController.GetCategories)
+	authorized.GET("/categories", appCtx.CategoriesController.GetCategories)
+	authorized.POST("/categories", appCtx.CategoriesController.CreateCategory)
+	authorized.GET("/categories/tree", appCtx.CategoriesController.GetCategoryTree)
+	authorized.GET("/categories/:category", appCtx.CategoriesController.GetCategory)
+	authorized.PUT("/categories/:category", appCtx.CategoriesController.UpdateCategory)
+	authorized.DELETE("/categories/:category", appCtx.CategoriesController.DeleteCategory)
+	authorized.PUT("/categories/:category/parent", appCtx.CategoriesController.SetCategoryParent)
 
 
 	// System Routes
 	router.GET("/ping", PingHandler)
 	router.GET("/health", HealthHandler)
+	router.GET("/ready", ReadyHandler(appCtx.Readiness))
+	router.GET("/replication", ReplicationHandler(appCtx.Replication))
+	router.GET("/replication/:id", ReplicationCatalogHandler(appCtx.Replication))
+	authorized.POST("/replication/resync", ResyncHandler(appCtx.Replication))
+	authorized.GET("/replication/dead-letters", appCtx.DeadLettersController.GetDeadLetters)
+	authorized.POST("/replication/dead-letters/:id/replay", appCtx.DeadLettersController.ReplayDeadLetter)
+	authorized.DELETE("/replication/dead-letters/:id", appCtx.DeadLettersController.DiscardDeadLetter)
 
 	// Swagger Route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -1,16 +1,84 @@
 package dto
 
+import "time"
+
 type CatalogRequest struct {
-	ID       string `json:"id"`
+	// ID is the hex ID of the updated catalog, it is ignored on create.
+	ID string `json:"id" mapstructure:"-"`
+	// Active is applied on create only, update keeps the lifecycle state of the catalog.
 	Active   bool   `json:"active"`
 	Category string `json:"category"`
 	Name     string `json:"name"`
 	Desc     string `json:"desc"`
 	Value    string `json:"value"`
+	// Version is the expected current version for update, zero skips the check.
+	Version int64 `json:"version"`
+	// ParentID is the hex ID of the parent catalog, empty for roots.
+	ParentID string `json:"parent_id" mapstructure:"-"`
+	// Translations of Name and Desc by locale, update keeps current translations when it is not set.
+	Translations map[string]Translation `json:"translations" mapstructure:"-" binding:"omitempty,dive,keys,oneof=ru en kk,endkeys"`
 } // @Name CatalogRequest
 
 type CatalogsRequest struct {
-	Category string `query:"category" json:"category" validate:"required"`
-	Query    string `query:"query" json:"query"`
-	Sorted   bool   `query:"sorted" json:"sorted" default:"true"`
-}// @Name CatalogsRequest
+	// Category is optional, catalogs of all categories are returned when it is empty.
+	Category string `form:"category" json:"category"`
+	Query    string `form:"query" json:"query"`
+	// Active filters catalogs by the active flag. When it is not set, only active catalogs are returned
+	// unless IncludeInactive is set.
+	Active          *bool  `form:"active" json:"active"`
+	IncludeInactive bool   `form:"include_inactive" json:"include_inactive"`
+	SortBy          string `form:"sort_by,default=name" json:"sort_by" binding:"omitempty,oneof=name created_at updated_at value" enums:"name,created_at,updated_at,value" default:"name"`
+	Order           string `form:"order,default=asc" json:"order" binding:"omitempty,oneof=asc desc" enums:"asc,desc" default:"asc"`
+	Page            int    `form:"page,default=1" json:"page" binding:"omitempty,min=1" default:"1"`
+	PageSize        int    `form:"page_size,default=50" json:"page_size" binding:"omitempty,min=1,max=1000" default:"50"`
+	// Cursor is the next_cursor of the previous page, page is ignored when it is set.
+	Cursor string `form:"cursor" json:"cursor"`
+} // @Name CatalogsRequest
+
+type SearchCatalogsRequest struct {
+	Query    string `form:"q" json:"q" binding:"required"`
+	Category string `form:"category" json:"category"`
+	Active   *bool  `form:"active" json:"active"`
+	// IncludeInactive returns inactive and archived catalogs when Active is not set
+	IncludeInactive bool `form:"include_inactive" json:"include_inactive"`
+	Fuzzy           bool `form:"fuzzy,default=true" json:"fuzzy" default:"true"`
+	Limit           int  `form:"limit,default=20" json:"limit" binding:"omitempty,min=1,max=100" default:"20"`
+} // @Name SearchCatalogsRequest
+
+type SuggestCatalogsRequest struct {
+	Prefix   string `form:"prefix" json:"prefix" binding:"required"`
+	Category string `form:"category" json:"category"`
+	Limit    int    `form:"limit,default=10" json:"limit" binding:"omitempty,min=1,max=50" default:"10"`
+} // @Name SuggestCatalogsRequest
+
+type LookupCatalogRequest struct {
+	Category string `form:"category" json:"category" binding:"required"`
+	Value    string `form:"value" json:"value" binding:"required"`
+	// IncludeInactive allows inactive and archived catalogs
+	IncludeInactive bool `form:"include_inactive" json:"include_inactive"`
+} // @Name LookupCatalogRequest
+
+type GetCatalogRequest struct {
+	// IncludeInactive allows inactive and archived catalogs
+	IncludeInactive bool `form:"include_inactive" json:"include_inactive"`
+} // @Name GetCatalogRequest
+
+type RecentCatalogsRequest struct {
+	// Since is RFC 3339 time, catalogs updated before it are skipped
+	Since time.Time `form:"since" json:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit int       `form:"limit,default=50" json:"limit" binding:"omitempty,min=1,max=1000" default:"50"`
+	// IncludeInactive returns inactive and archived catalogs
+	IncludeInactive bool `form:"include_inactive" json:"include_inactive"`
+} // @Name RecentCatalogsRequest
+
+type SubtreeRequest struct {
+	// Depth limits levels below the catalog, zero returns the whole subtree
+	Depth int `form:"depth" json:"depth" binding:"omitempty,min=0,max=64"`
+	// IncludeInactive returns inactive and archived descendants
+	IncludeInactive bool `form:"include_inactive" json:"include_inactive"`
+} // @Name SubtreeRequest
+
+type AncestorsRequest struct {
+	// IncludeInactive returns inactive and archived catalogs
+	IncludeInactive bool `form:"include_inactive" json:"include_inactive"`
+} // @Name AncestorsRequest
@@ -0,0 +1,30 @@
+package dto
+
+import "encoding/json"
+
+type CategoryParentRequest struct {
+	// Parent is the name of the parent category, empty makes the category a root.
+	Parent string `json:"parent"`
+} // @Name CategoryParentRequest
+
+type CategoryRequest struct {
+	// Name is required on create, on update the name is taken from the path.
+	Name        string       `json:"name"`
+	DisplayName string       `json:"display_name"`
+	Description string       `json:"description"`
+	OwnerTeam   string       `json:"owner_team"`
+	SortOrder   int          `json:"sort_order"`
+	Parent      string       `json:"parent"`
+	Schema      *ValueSchema `json:"schema" binding:"omitempty"`
+	// Translations of DisplayName as name and Description as desc by locale.
+	Translations map[string]Translation `json:"translations" binding:"omitempty,dive,keys,oneof=ru en kk,endkeys"`
+} // @Name CategoryRequest
+
+// ValueSchema restricts values of catalogs of the category. Pattern must match the whole value,
+// Enum lists allowed values, JSONSchema must accept the value as a string or as decoded JSON.
+type ValueSchema struct {
+	Type       string          `json:"type" binding:"required,oneof=regex enum json_schema" enums:"regex,enum,json_schema"`
+	Pattern    string          `json:"pattern,omitempty" binding:"required_if=Type regex"`
+	Enum       []string        `json:"enum,omitempty" binding:"required_if=Type enum"`
+	JSONSchema json.RawMessage `json:"json_schema,omitempty" binding:"required_if=Type json_schema" swaggertype:"object"`
+} // @Name ValueSchema
@@ -0,0 +1,6 @@
+package dto
+
+type DeadLettersRequest struct {
+	Limit  int64 `form:"limit" json:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int64 `form:"offset" json:"offset" binding:"omitempty,min=0"`
+} // @Name DeadLettersRequest
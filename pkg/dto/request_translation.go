@@ -0,0 +1,25 @@
+package dto
+
+// Translation is a name and a description in one locale. Empty fields fall back to the next locale.
+type Translation struct {
+	Name string `json:"name,omitempty"`
+	Desc string `json:"desc,omitempty"`
+} // @Name Translation
+
+type ExportTranslationsRequest struct {
+	// Category is optional, catalogs of all categories are exported when it is empty.
+	Category string `form:"category" json:"category"`
+	// IncludeInactive exports inactive and archived catalogs
+	IncludeInactive bool `form:"include_inactive" json:"include_inactive"`
+} // @Name ExportTranslationsRequest
+
+type CatalogTranslationsRequest struct {
+	ID           string                 `json:"id" binding:"required"`
+	Translations map[string]Translation `json:"translations" binding:"required,dive,keys,oneof=ru en kk,endkeys"`
+} // @Name CatalogTranslationsRequest
+
+type ImportTranslationsRequest struct {
+	Items []CatalogTranslationsRequest `json:"items" binding:"required,min=1,max=1000,dive"`
+	// Replace drops translations to locales missing in the item, otherwise only the given locales change.
+	Replace bool `json:"replace"`
+} // @Name ImportTranslationsRequest
@@ -3,12 +3,17 @@ package dto
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type CatalogResponse struct {
-	ID       primitive.ObjectID `json:"id"`
-	Active   bool   `json:"active"`
-	Category string `json:"category"`
-	Name     string `json:"name"`
-	Desc     string `json:"desc"`
-	Value    string `json:"value"`
+	ID       primitive.ObjectID  `json:"id"`
+	Active   bool                `json:"active"`
+	Category string              `json:"category"`
+	Name     string              `json:"name"`
+	Desc     string              `json:"desc"`
+	Value    string              `json:"value"`
+	Version  int64               `json:"version"`
+	Status   string              `json:"status" enums:"active,inactive,archived"`
+	ParentID *primitive.ObjectID `json:"parent_id,omitempty"`
+	// Translations are returned by create and update, reads return Name and Desc in the negotiated locale.
+	Translations map[string]Translation `json:"translations,omitempty"`
 } // @Name CatalogResponse
 
 type CreateCatalogResponse struct {
@@ -30,11 +35,6 @@ type GetCatalogsResponse struct {
 	Meta    ResponseMetaList  `json:"meta"`
 }// @Name GetCatalogsResponse
 
-type GetCategoriesResponse struct {
-	Payload []string         `json:"payload" mapstructure:",squash"`
-	Meta    ResponseMetaList `json:"meta"`
-}// @Name GetCategoriesResponse
-
 type UpdateCatalogResponse struct {
 	Payload struct {
 		CatalogResponse `mapstructure:",squash"`
@@ -47,4 +47,61 @@ type DeleteCatalogResponse struct {
 		Active bool `json:"active"`
 	} `json:"payload"`
 	Meta ResponseMeta `json:"meta"`
-}// @Name DeleteCatalogResponse
\ No newline at end of file
+}// @Name DeleteCatalogResponse
+
+type PurgeCatalogResponse struct {
+	Payload struct {
+		ID     string `json:"id"`
+		Purged bool   `json:"purged"`
+	} `json:"payload"`
+	Meta ResponseMeta `json:"meta"`
+} // @Name PurgeCatalogResponse
+
+type SearchCatalogResponse struct {
+	CatalogResponse `mapstructure:",squash"`
+	// Score is the relevance of the catalog, higher is better
+	Score float64 `json:"score"`
+} // @Name SearchCatalogResponse
+
+type SearchCatalogsResponse struct {
+	Payload []SearchCatalogResponse `json:"payload"`
+	Meta    ResponseMetaList        `json:"meta"`
+} // @Name SearchCatalogsResponse
+
+type SuggestionResponse struct {
+	ID       primitive.ObjectID `json:"id"`
+	Category string             `json:"category"`
+	Name     string             `json:"name"`
+	Value    string             `json:"value"`
+} // @Name SuggestionResponse
+
+type SuggestCatalogsResponse struct {
+	Payload []SuggestionResponse `json:"payload"`
+	Meta    ResponseMetaList     `json:"meta"`
+} // @Name SuggestCatalogsResponse
+
+type TreeNodeResponse struct {
+	CatalogResponse `mapstructure:",squash"`
+	Children        []TreeNodeResponse `json:"children,omitempty"`
+} // @Name TreeNodeResponse
+
+type GetSubtreeResponse struct {
+	Payload TreeNodeResponse `json:"payload"`
+	Meta    ResponseMeta     `json:"meta"`
+} // @Name GetSubtreeResponse
+
+type GetAncestorsResponse struct {
+	Payload []CatalogResponse `json:"payload"`
+	Meta    ResponseMetaList  `json:"meta"`
+} // @Name GetAncestorsResponse
+
+type BreadcrumbResponse struct {
+	ID       primitive.ObjectID `json:"id"`
+	Category string             `json:"category"`
+	Name     string             `json:"name"`
+} // @Name BreadcrumbResponse
+
+type GetBreadcrumbsResponse struct {
+	Payload []BreadcrumbResponse `json:"payload"`
+	Meta    ResponseMetaList     `json:"meta"`
+} // @Name GetBreadcrumbsResponse
@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+type CategoryResponse struct {
+	Name        string       `json:"name"`
+	DisplayName string       `json:"display_name,omitempty"`
+	Description string       `json:"description,omitempty"`
+	OwnerTeam   string       `json:"owner_team,omitempty"`
+	SortOrder   int          `json:"sort_order"`
+	Parent      string       `json:"parent,omitempty"`
+	Schema      *ValueSchema `json:"schema,omitempty"`
+	// DisplayName and Description are in the negotiated locale, Translations have all locales.
+	Translations map[string]Translation `json:"translations,omitempty"`
+	// Managed is false for categories which only have catalogs and no metadata.
+	Managed   bool       `json:"managed"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+} // @Name CategoryResponse
+
+type CategoryTreeNodeResponse struct {
+	Name        string                     `json:"name"`
+	DisplayName string                     `json:"display_name"`
+	Children    []CategoryTreeNodeResponse `json:"children,omitempty"`
+} // @Name CategoryTreeNodeResponse
+
+type GetCategoriesResponse struct {
+	Payload []CategoryResponse `json:"payload"`
+	Meta    ResponseMetaList   `json:"meta"`
+} // @Name GetCategoriesResponse
+
+type GetCategoryResponse struct {
+	Payload CategoryResponse `json:"payload"`
+	Meta    ResponseMeta     `json:"meta"`
+} // @Name GetCategoryResponse
+
+type CreateCategoryResponse struct {
+	Payload CategoryResponse `json:"payload"`
+	Meta    ResponseMeta     `json:"meta"`
+} // @Name CreateCategoryResponse
+
+type GetCategoryTreeResponse struct {
+	Payload []CategoryTreeNodeResponse `json:"payload"`
+	Meta    ResponseMetaList           `json:"meta"`
+} // @Name GetCategoryTreeResponse
+
+type UpdateCategoryResponse struct {
+	Payload CategoryResponse `json:"payload"`
+	Meta    ResponseMeta     `json:"meta"`
+} // @Name UpdateCategoryResponse
+
+type DeleteCategoryResponse struct {
+	Payload struct {
+		Name string `json:"name"`
+	} `json:"payload"`
+	Meta ResponseMeta `json:"meta"`
+} // @Name DeleteCategoryResponse
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type DeadLetterResponse struct {
+	ID        primitive.ObjectID `json:"id"`
+	Node      string             `json:"node"`
+	Sequence  uint64             `json:"sequence"`
+	Operation *OperationResponse `json:"operation,omitempty"`
+	Payload   string             `json:"payload,omitempty"`
+	Attempts  int                `json:"attempts"`
+	Error     string             `json:"error"`
+	CreatedAt time.Time          `json:"created_at"`
+} // @Name DeadLetterResponse
+
+type OperationResponse struct {
+	Type      string           `json:"type"`
+	Method    string           `json:"method"`
+	Catalog   *CatalogResponse `json:"catalog,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+} // @Name OperationResponse
+
+type GetDeadLettersResponse struct {
+	Payload []DeadLetterResponse `json:"payload"`
+	Meta    ResponseMetaList     `json:"meta"`
+} // @Name GetDeadLettersResponse
+
+type DeadLetterActionResponse struct {
+	Payload struct {
+		ID     string `json:"id"`
+		Action string `json:"action"`
+	} `json:"payload"`
+	Meta ResponseMeta `json:"meta"`
+} // @Name DeadLetterActionResponse
@@ -6,6 +6,7 @@ type ResponseMetaList struct {
 	CurrentPage  int32  `json:"current_page,omitempty"`
 	PageSize     int    `json:"page_size,omitempty"`
 	NodeID       string `json:"node_id,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
 } // @Name ResponseMetaList
 
 type ResponseMeta struct {
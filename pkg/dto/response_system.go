@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type PingResponse struct {
 	Payload struct {
 		Ping string `json:"pong"`
@@ -7,3 +9,40 @@ type PingResponse struct {
 	Meta ResponseMeta `json:"meta"`
 } // @Name PingResponse
 
+type ReplicationResponse struct {
+	Payload struct {
+		Ready             bool             `json:"ready"`
+		StaleOperations   uint64           `json:"stale_operations"`
+		DriftedCategories uint64           `json:"drifted_categories"`
+		DeadLettered      uint64           `json:"dead_lettered"`
+		LastReconcile     *ReconcileReport `json:"last_reconcile,omitempty"`
+	} `json:"payload"`
+	Meta ResponseMeta `json:"meta"`
+} // @Name ReplicationResponse
+
+type ReplicationCatalogResponse struct {
+	Payload struct {
+		ID           string `json:"id"`
+		LastSequence uint64 `json:"last_sequence"`
+	} `json:"payload"`
+	Meta ResponseMeta `json:"meta"`
+} // @Name ReplicationCatalogResponse
+
+type CategoryDrift struct {
+	Category string `json:"category"`
+	Missing  int    `json:"missing"`
+	Extra    int    `json:"extra"`
+	Changed  int    `json:"changed"`
+} // @Name CategoryDrift
+
+type ReconcileReport struct {
+	StartedAt  time.Time       `json:"started_at"`
+	DurationMs int64           `json:"duration_ms"`
+	Categories int             `json:"categories"`
+	Drifted    []CategoryDrift `json:"drifted"`
+} // @Name ReconcileReport
+
+type ResyncResponse struct {
+	Payload ReconcileReport `json:"payload"`
+	Meta    ResponseMeta    `json:"meta"`
+} // @Name ResyncResponse
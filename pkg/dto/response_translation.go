@@ -0,0 +1,31 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type CatalogTranslationsResponse struct {
+	ID       primitive.ObjectID `json:"id"`
+	Category string             `json:"category"`
+	Value    string             `json:"value"`
+	// Name and Desc are in the default locale
+	Name         string                 `json:"name"`
+	Desc         string                 `json:"desc"`
+	Translations map[string]Translation `json:"translations"`
+} // @Name CatalogTranslationsResponse
+
+type ExportTranslationsResponse struct {
+	Payload []CatalogTranslationsResponse `json:"payload"`
+	Meta    ResponseMetaList              `json:"meta"`
+} // @Name ExportTranslationsResponse
+
+type ImportErrorResponse struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+} // @Name ImportErrorResponse
+
+type ImportTranslationsResponse struct {
+	Payload struct {
+		Updated int                   `json:"updated"`
+		Failed  []ImportErrorResponse `json:"failed"`
+	} `json:"payload"`
+	Meta ResponseMeta `json:"meta"`
+} // @Name ImportTranslationsResponse
@@ -6,10 +6,10 @@ type OperationType string
 type OperationMethod string
 
 type Operation struct {
-	Type      OperationType   `json:"type"`
-	Method    OperationMethod `json:"method"`
-	Catalog   *Catalog        `json:"catalog,omitempty"`
-	Timestamp time.Time       `json:"timestamp"`
+	Type      OperationType   `bson:"type" json:"type"`
+	Method    OperationMethod `bson:"method" json:"method"`
+	Catalog   *Catalog        `bson:"catalog,omitempty" json:"catalog,omitempty"`
+	Timestamp time.Time       `bson:"timestamp" json:"timestamp"`
 }
 
 const (
@@ -1,10 +1,22 @@
 package models
 
 import (
+	"github.com/rusrafkasimov/catalogs/internal/locale"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 )
 
+// CatalogStatus is the lifecycle state of a catalog. Active catalogs are visible to reads, inactive
+// and archived ones are hidden unless requested. Archived catalogs can't be updated. A purged catalog
+// is hard-deleted from Mongo and memory storage.
+type CatalogStatus string
+
+const (
+	StatusActive   CatalogStatus = "active"
+	StatusInactive CatalogStatus = "inactive"
+	StatusArchived CatalogStatus = "archived"
+)
+
 type Catalog struct {
 	ID       primitive.ObjectID `bson:"_id" json:"id"`
 	Active   bool               `bson:"active" json:"active"`
@@ -12,11 +24,94 @@ type Catalog struct {
 	Name     string             `bson:"name" json:"name"`
 	Desc     string             `bson:"desc" json:"desc"`
 	Value    string             `bson:"value" json:"value"`
+	// Translations of Name and Desc by locale. Name and Desc are in the default locale.
+	Translations map[string]Translation `bson:"translations,omitempty" json:"translations,omitempty"`
+	// ParentID refers to the parent catalog of the category or of its parent category, nil for roots.
+	ParentID *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	// Status is kept in sync with Active, use SetStatus to change it.
+	Status CatalogStatus `bson:"status" json:"status"`
+	// Version grows on every write. Replication uses it to skip stale operations.
+	Version int64 `bson:"version" json:"version"`
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
+// Translation is a name and a description in one locale. Empty fields fall back to the next locale.
+type Translation struct {
+	Name string `bson:"name,omitempty" json:"name,omitempty"`
+	Desc string `bson:"desc,omitempty" json:"desc,omitempty"`
+}
+
+// Localized returns the name and the description in the locale, falling back field by field
+// to locale.Chain and then to Name and Desc.
+func (c *Catalog) Localized(loc string) Translation {
+	return localize(c.Translations, loc, Translation{Name: c.Name, Desc: c.Desc})
+}
+
+// localize resolves the translation of the locale with the fallback texts.
+func localize(translations map[string]Translation, loc string, fallback Translation) Translation {
+	var out Translation
+	if len(translations) > 0 {
+		for _, l := range locale.Chain(loc) {
+			t := translations[l]
+			if out.Name == "" {
+				out.Name = t.Name
+			}
+			if out.Desc == "" {
+				out.Desc = t.Desc
+			}
+		}
+	}
+
+	if out.Name == "" {
+		out.Name = fallback.Name
+	}
+	if out.Desc == "" {
+		out.Desc = fallback.Desc
+	}
+
+	return out
+}
+
+// EffectiveStatus returns the lifecycle state. Catalogs written before states have it derived from Active.
+func (c *Catalog) EffectiveStatus() CatalogStatus {
+	if c.Status != "" {
+		return c.Status
+	}
+
+	if c.Active {
+		return StatusActive
+	}
+
+	return StatusInactive
+}
+
+// SetStatus changes the lifecycle state and the active flag.
+func (c *Catalog) SetStatus(status CatalogStatus) {
+	c.Status = status
+	c.Active = status == StatusActive
+}
+
+// CanTransition reports whether a catalog may change its state from one to another.
+// Keeping the same state is allowed.
+func CanTransition(from, to CatalogStatus) bool {
+	if from == to {
+		return true
+	}
+
+	switch from {
+	case StatusActive:
+		return to == StatusInactive || to == StatusArchived
+	case StatusInactive:
+		return to == StatusActive || to == StatusArchived
+	case StatusArchived:
+		return to == StatusActive
+	}
+
+	return false
+}
+
 type ByName []*Catalog
 
 func (a ByName) Len() int {
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/xeipuuv/gojsonschema"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SchemaType is the kind of the allowed value schema of a category.
+type SchemaType string
+
+const (
+	SchemaRegex SchemaType = "regex"
+	SchemaEnum  SchemaType = "enum"
+	SchemaJSON  SchemaType = "json_schema"
+)
+
+// Category keeps metadata of catalogs with the same Category. Parent is the name of the parent
+// category, empty for roots. Catalogs of a category without Schema may have any value.
+type Category struct {
+	Name        string       `bson:"_id" json:"name"`
+	DisplayName string       `bson:"display_name,omitempty" json:"display_name,omitempty"`
+	Description string       `bson:"description,omitempty" json:"description,omitempty"`
+	OwnerTeam   string       `bson:"owner_team,omitempty" json:"owner_team,omitempty"`
+	SortOrder   int          `bson:"sort_order" json:"sort_order"`
+	Parent      string       `bson:"parent,omitempty" json:"parent,omitempty"`
+	Schema      *ValueSchema `bson:"schema,omitempty" json:"schema,omitempty"`
+	// Translations of DisplayName as Name and Description as Desc by locale.
+	Translations map[string]Translation `bson:"translations,omitempty" json:"translations,omitempty"`
+	// Revision is bumped by every write of a catalog of the category, so catalog writes conflict
+	// with concurrent changes of the schema.
+	Revision int64 `bson:"revision" json:"-"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Localized returns the display name and the description in the locale. The display name falls back
+// to Name.
+func (c *Category) Localized(loc string) Translation {
+	fallback := Translation{Name: c.DisplayName, Desc: c.Description}
+	if fallback.Name == "" {
+		fallback.Name = c.Name
+	}
+
+	return localize(c.Translations, loc, fallback)
+}
+
+// ValueSchema restricts values of catalogs of a category. Pattern must match the whole value, Enum lists
+// allowed values and JSONSchema is a JSON schema document which must accept the value as a string
+// or, when the value is JSON, the decoded value.
+type ValueSchema struct {
+	Type       SchemaType `bson:"type" json:"type"`
+	Pattern    string     `bson:"pattern,omitempty" json:"pattern,omitempty"`
+	Enum       []string   `bson:"enum,omitempty" json:"enum,omitempty"`
+	JSONSchema string     `bson:"json_schema,omitempty" json:"json_schema,omitempty"`
+}
+
+// ValueValidator returns an error describing why the value is not allowed.
+type ValueValidator func(value string) error
+
+// Compile checks the schema and returns the validator of values.
+func (s *ValueSchema) Compile() (ValueValidator, error) {
+	switch s.Type {
+	case SchemaRegex:
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return nil, err
+		}
+		re := regexp.MustCompile(`^(?:` + s.Pattern + `)$`)
+
+		return func(value string) error {
+			if !re.MatchString(value) {
+				return fmt.Errorf("value %q does not match %q", value, s.Pattern)
+			}
+			return nil
+		}, nil
+	case SchemaEnum:
+		if len(s.Enum) == 0 {
+			return nil, fmt.Errorf("enum is empty")
+		}
+
+		allowed := make(map[string]struct{}, len(s.Enum))
+		for _, value := range s.Enum {
+			allowed[value] = struct{}{}
+		}
+
+		return func(value string) error {
+			if _, ok := allowed[value]; !ok {
+				return fmt.Errorf("value %q is not one of %q", value, s.Enum)
+			}
+			return nil
+		}, nil
+	case SchemaJSON:
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(s.JSONSchema))
+		if err != nil {
+			return nil, err
+		}
+
+		return func(value string) error {
+			// Reasons are reported for the decoded value when the value is JSON
+			documents := []interface{}{value}
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+				documents = []interface{}{decoded, value}
+			}
+
+			var reasons []string
+			for i, document := range documents {
+				result, err := schema.Validate(gojsonschema.NewGoLoader(document))
+				if err != nil {
+					return err
+				}
+				if result.Valid() {
+					return nil
+				}
+
+				if i == 0 {
+					for _, reason := range result.Errors() {
+						reasons = append(reasons, reason.String())
+					}
+				}
+			}
+
+			return fmt.Errorf("value %q: %s", value, strings.Join(reasons, "; "))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown schema type %q", s.Type)
+	}
+}
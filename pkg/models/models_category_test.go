@@ -0,0 +1,105 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// check runs the validator of the schema on values, allowed maps a value to whether it must pass.
+func check(t *testing.T, schema ValueSchema, allowed map[string]bool) {
+	t.Helper()
+
+	validate, err := schema.Compile()
+	if err != nil {
+		t.Fatalf("Compile(%+v): %v", schema, err)
+	}
+
+	for value, ok := range allowed {
+		err := validate(value)
+		if ok && err != nil {
+			t.Errorf("%s schema rejected %q: %v", schema.Type, value, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("%s schema allowed %q", schema.Type, value)
+		}
+	}
+}
+
+func TestValueSchemaRegexMatchesWholeValue(t *testing.T) {
+	check(t, ValueSchema{Type: SchemaRegex, Pattern: `[A-Z]{2}`}, map[string]bool{
+		"RU":   true,
+		"ru":   false,
+		"RUS":  false,
+		"xRU":  false,
+		"RU\n": false,
+		"":     false,
+	})
+
+	// Alternatives are anchored as a group
+	check(t, ValueSchema{Type: SchemaRegex, Pattern: `red|green`}, map[string]bool{
+		"red":      true,
+		"green":    true,
+		"redgreen": false,
+		"reddish":  false,
+		"dark red": false,
+	})
+
+	if _, err := (&ValueSchema{Type: SchemaRegex, Pattern: `[a-`}).Compile(); err == nil {
+		t.Fatal("invalid pattern compiled")
+	}
+}
+
+func TestValueSchemaEnum(t *testing.T) {
+	check(t, ValueSchema{Type: SchemaEnum, Enum: []string{"small", "large"}}, map[string]bool{
+		"small":  true,
+		"large":  true,
+		"Small":  false,
+		"medium": false,
+		"":       false,
+	})
+
+	if _, err := (&ValueSchema{Type: SchemaEnum}).Compile(); err == nil {
+		t.Fatal("empty enum compiled")
+	}
+}
+
+func TestValueSchemaJSON(t *testing.T) {
+	// A JSON value is checked decoded and as a string
+	check(t, ValueSchema{Type: SchemaJSON, JSONSchema: `{"type": "integer", "minimum": 1}`}, map[string]bool{
+		"5":   true,
+		"0":   false,
+		"1.5": false,
+		"abc": false,
+	})
+	check(t, ValueSchema{Type: SchemaJSON, JSONSchema: `{"type": "string", "maxLength": 3}`}, map[string]bool{
+		"abc":   true,
+		"abcd":  false,
+		"123":   true,
+		`"ab"`:  true,
+		"12345": false,
+	})
+	check(t, ValueSchema{Type: SchemaJSON, JSONSchema: `{"type": "object", "required": ["code"]}`}, map[string]bool{
+		`{"code": "RU"}`: true,
+		`{"name": "RU"}`: false,
+		"RU":             false,
+	})
+
+	// Reasons are reported for the decoded value
+	validate, err := (&ValueSchema{Type: SchemaJSON, JSONSchema: `{"type": "integer", "minimum": 1}`}).Compile()
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if err := validate("0"); err == nil || !strings.Contains(err.Error(), "greater than or equal to 1") {
+		t.Fatalf("error %v does not report the minimum", err)
+	}
+
+	if _, err := (&ValueSchema{Type: SchemaJSON, JSONSchema: `{"type": 1`}).Compile(); err == nil {
+		t.Fatal("invalid JSON schema compiled")
+	}
+}
+
+func TestValueSchemaUnknownType(t *testing.T) {
+	if _, err := (&ValueSchema{Type: "xml"}).Compile(); err == nil {
+		t.Fatal("unknown schema type compiled")
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// DeadLetter is a replication event which could not be decoded or applied after the maximum number
+// of deliveries. Partition is set for partitioned sources, the sequence is unique within it. Operation is nil when the event could not be decoded, Payload keeps its raw data.
+type DeadLetter struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Node      string             `bson:"node" json:"node"`
+	Sequence  uint64             `bson:"sequence" json:"sequence"`
+	Partition *int               `bson:"partition,omitempty" json:"partition,omitempty"`
+	Operation *Operation         `bson:"operation,omitempty" json:"operation,omitempty"`
+	Payload   string             `bson:"payload,omitempty" json:"payload,omitempty"`
+	Attempts  int                `bson:"attempts" json:"attempts"`
+	Error     string             `bson:"error" json:"error"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending"
+	OutboxStatusDone    OutboxStatus = "done"
+)
+
+// OutboxEvent is an operation written in the same transaction as the catalog change.
+// It is published to the event queue by the outbox relay.
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	Operation     *Operation         `bson:"operation" json:"operation"`
+	Status        OutboxStatus       `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	PublishedAt   *time.Time         `bson:"published_at,omitempty" json:"published_at,omitempty"`
+}
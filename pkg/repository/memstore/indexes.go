@@ -0,0 +1,193 @@
+package memstore
+
+import (
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"sort"
+	"time"
+)
+
+const defaultRecentLimit = 50
+
+// updatedEntry is a catalog in the UpdatedAt order.
+type updatedEntry struct {
+	at      time.Time
+	id      string
+	catalog *models.Catalog
+}
+
+// catalogIndexes are secondary indexes of catalogs. They are guarded by the catalog lock.
+type catalogIndexes struct {
+	// active maps category and allCategories to IDs of active catalogs
+	active map[string]map[string]struct{}
+	// byValue maps category and value to IDs of catalogs
+	byValue map[string]map[string]map[string]struct{}
+	// updated is sorted by UpdatedAt and ID
+	updated []updatedEntry
+	// children maps ID of the parent catalog to IDs of its children
+	children map[string]map[string]struct{}
+}
+
+func newCatalogIndexes() catalogIndexes {
+	return catalogIndexes{
+		active:   make(map[string]map[string]struct{}),
+		byValue:  make(map[string]map[string]map[string]struct{}),
+		children: make(map[string]map[string]struct{}),
+	}
+}
+
+func (x *catalogIndexes) add(id string, catalog *models.Catalog) {
+	x.link(id, catalog)
+
+	entry := updatedEntry{at: catalog.UpdatedAt, id: id, catalog: catalog}
+	i := entry.search(x.updated)
+	x.updated = append(x.updated, updatedEntry{})
+	copy(x.updated[i+1:], x.updated[i:])
+	x.updated[i] = entry
+}
+
+// addAll adds catalogs which are not indexed yet and sorts updated entries once.
+func (x *catalogIndexes) addAll(catalogs []*models.Catalog) {
+	for _, catalog := range catalogs {
+		id := catalog.ID.String()
+		x.link(id, catalog)
+		x.updated = append(x.updated, updatedEntry{at: catalog.UpdatedAt, id: id, catalog: catalog})
+	}
+
+	sort.Slice(x.updated, func(i, j int) bool {
+		return x.updated[i].less(x.updated[j])
+	})
+}
+
+// link adds the catalog to the map indexes.
+func (x *catalogIndexes) link(id string, catalog *models.Catalog) {
+	if catalog.Active {
+		for _, category := range []string{allCategories, catalog.Category} {
+			ids, ok := x.active[category]
+			if !ok {
+				ids = make(map[string]struct{})
+				x.active[category] = ids
+			}
+			ids[id] = struct{}{}
+		}
+	}
+
+	values, ok := x.byValue[catalog.Category]
+	if !ok {
+		values = make(map[string]map[string]struct{})
+		x.byValue[catalog.Category] = values
+	}
+	ids, ok := values[catalog.Value]
+	if !ok {
+		ids = make(map[string]struct{})
+		values[catalog.Value] = ids
+	}
+	ids[id] = struct{}{}
+
+	if catalog.ParentID != nil {
+		parent := catalog.ParentID.String()
+		children, ok := x.children[parent]
+		if !ok {
+			children = make(map[string]struct{})
+			x.children[parent] = children
+		}
+		children[id] = struct{}{}
+	}
+}
+
+func (x *catalogIndexes) remove(id string, catalog *models.Catalog) {
+	for _, category := range []string{allCategories, catalog.Category} {
+		delete(x.active[category], id)
+		if len(x.active[category]) == 0 {
+			delete(x.active, category)
+		}
+	}
+
+	if values, ok := x.byValue[catalog.Category]; ok {
+		delete(values[catalog.Value], id)
+		if len(values[catalog.Value]) == 0 {
+			delete(values, catalog.Value)
+		}
+		if len(values) == 0 {
+			delete(x.byValue, catalog.Category)
+		}
+	}
+
+	entry := updatedEntry{at: catalog.UpdatedAt, id: id}
+	if i := entry.search(x.updated); i < len(x.updated) && x.updated[i].id == id {
+		x.updated = append(x.updated[:i], x.updated[i+1:]...)
+	}
+
+	if catalog.ParentID != nil {
+		parent := catalog.ParentID.String()
+		delete(x.children[parent], id)
+		if len(x.children[parent]) == 0 {
+			delete(x.children, parent)
+		}
+	}
+}
+
+// search returns the position of the entry in the sorted entries.
+func (e updatedEntry) search(entries []updatedEntry) int {
+	return sort.Search(len(entries), func(i int) bool {
+		if !entries[i].at.Equal(e.at) {
+			return entries[i].at.After(e.at)
+		}
+		return entries[i].id >= e.id
+	})
+}
+
+// less reports whether the entry goes before the other one.
+func (e updatedEntry) less(other updatedEntry) bool {
+	if !e.at.Equal(other.at) {
+		return e.at.Before(other.at)
+	}
+	return e.id < other.id
+}
+
+// GetCatalogByValue returns the catalog of the category with the value. When several catalogs have the
+// same value an active catalog is preferred, then the latest updated one and then the greatest ID.
+func (m *memStore) GetCatalogByValue(category, value string) (*models.Catalog, bool) {
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	var found *models.Catalog
+	var foundEntry updatedEntry
+	for id := range m.catalog.indexes.byValue[category][value] {
+		catalog, ok := m.catalog.data[id]
+		if !ok {
+			continue
+		}
+
+		entry := updatedEntry{at: catalog.UpdatedAt, id: id}
+		if found == nil || catalog.Active && !found.Active || catalog.Active == found.Active && foundEntry.less(entry) {
+			found, foundEntry = catalog, entry
+		}
+	}
+
+	return found, found != nil
+}
+
+// GetRecentlyChanged returns up to limit catalogs updated at or after since, most recent first.
+// When activeOnly is set, inactive and archived catalogs are skipped.
+func (m *memStore) GetRecentlyChanged(since time.Time, limit int, activeOnly bool) []*models.Catalog {
+	if limit <= 0 {
+		limit = defaultRecentLimit
+	}
+
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	var out []*models.Catalog
+	for i := len(m.catalog.indexes.updated) - 1; i >= 0 && len(out) < limit; i-- {
+		entry := m.catalog.indexes.updated[i]
+		if entry.at.Before(since) {
+			break
+		}
+		if activeOnly && !entry.catalog.Active {
+			continue
+		}
+		out = append(out, entry.catalog)
+	}
+
+	return out
+}
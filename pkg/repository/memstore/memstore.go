@@ -6,24 +6,43 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 type MemStore interface {
 	UpsertCatalog(catalog *models.Catalog)
 	UpsertCatalogByCategory(catalog *models.Catalog)
+	LoadCatalogs(catalogs []*models.Catalog)
 	GetCatalog(id string) (*models.Catalog, bool)
 	GetCatalogs(ids []string, sorted bool) []*models.Catalog
 	GetCategories() []string
 	GetCatalogByCategoryAndQuery(category string, query string, sorted bool) ([]*models.Catalog, bool)
 	RemoveCatalog(id string)
+	GetAllCatalogs() []*models.Catalog
+	FindCatalogs(q Query) (Page, bool, error)
+	SearchCatalogs(q SearchQuery) []SearchResult
+	SuggestCatalogs(prefix, category, locale string, limit int) []*models.Catalog
+	GetCatalogByValue(category, value string) (*models.Catalog, bool)
+	GetRecentlyChanged(since time.Time, limit int, activeOnly bool) []*models.Catalog
+	GetSubtree(id string, depth int, activeOnly bool) (*TreeNode, bool)
+	GetAncestors(id string, activeOnly bool) ([]*models.Catalog, bool)
 }
 
 type memStore struct {
 	context context.Context
 	catalog struct {
 		sync.RWMutex
-		data     map[string]*models.Catalog
+		data map[string]*models.Catalog
+		// category maps category to IDs of its catalogs and their active flag
 		category map[string]map[string]bool
+		indexes  catalogIndexes
+
+		// sorted caches catalogs of a category sorted by a field, see FindCatalogs
+		sortMu sync.Mutex
+		sorted map[string]map[sortOrder][]*models.Catalog
+
+		search searchIndex
+		names  nameIndex
 	}
 }
 
@@ -33,6 +52,10 @@ func NewMemStore(context context.Context) *memStore {
 	}
 	m.catalog.data = make(map[string]*models.Catalog)
 	m.catalog.category = make(map[string]map[string]bool)
+	m.catalog.sorted = make(map[string]map[sortOrder][]*models.Catalog)
+	m.catalog.search = newSearchIndex()
+	m.catalog.names = make(nameIndex)
+	m.catalog.indexes = newCatalogIndexes()
 	return m
 }
 
@@ -40,23 +63,56 @@ func (m *memStore) UpsertCatalog(catalog *models.Catalog) {
 	m.catalog.Lock()
 	defer m.catalog.Unlock()
 
-	m.catalog.data[catalog.ID.String()] = catalog
+	m.put(catalog)
 }
 
 func (m *memStore) UpsertCatalogByCategory(catalog *models.Catalog) {
 	m.catalog.Lock()
 	defer m.catalog.Unlock()
 
-	item, ok := m.catalog.category[catalog.Category]
-	if !ok {
+	if _, ok := m.catalog.category[catalog.Category]; !ok {
 		m.catalog.category[catalog.Category] = make(map[string]bool)
 	}
 
-	if item[catalog.ID.String()] {
-		return
+	m.catalog.category[catalog.Category][catalog.ID.String()] = catalog.Active
+}
+
+// LoadCatalogs stores catalogs like UpsertCatalog and UpsertCatalogByCategory do, but sorts indexes once
+// after adding all of them, so filling memory storage takes O(n log n). Of catalogs with the same ID
+// the last one is stored.
+func (m *memStore) LoadCatalogs(catalogs []*models.Catalog) {
+	m.catalog.Lock()
+	defer m.catalog.Unlock()
+
+	latest := make(map[string]*models.Catalog, len(catalogs))
+	for _, catalog := range catalogs {
+		latest[catalog.ID.String()] = catalog
 	}
 
-	m.catalog.category[catalog.Category][catalog.ID.String()] = catalog.Active
+	added := make([]*models.Catalog, 0, len(latest))
+	categories := make(map[string]bool)
+	for id, catalog := range latest {
+		if prev, ok := m.catalog.data[id]; ok {
+			m.drop(id)
+			delete(m.catalog.category[prev.Category], id)
+		}
+
+		m.catalog.data[id] = catalog
+		if _, ok := m.catalog.category[catalog.Category]; !ok {
+			m.catalog.category[catalog.Category] = make(map[string]bool)
+		}
+		m.catalog.category[catalog.Category][id] = catalog.Active
+
+		categories[catalog.Category] = true
+		added = append(added, catalog)
+	}
+
+	for category := range categories {
+		m.invalidateSorted(category)
+	}
+	m.catalog.search.addAll(added)
+	m.catalog.names.addAll(added)
+	m.catalog.indexes.addAll(added)
 }
 
 func (m *memStore) GetCatalog(id string) (*models.Catalog, bool) {
@@ -72,6 +128,11 @@ func (m *memStore) GetCatalogs(ids []string, sorted bool) []*models.Catalog {
 	m.catalog.RLock()
 	defer m.catalog.RUnlock()
 
+	return m.catalogsByIDs(ids, sorted)
+}
+
+// catalogsByIDs returns stored catalogs with the IDs. The caller holds the read lock.
+func (m *memStore) catalogsByIDs(ids []string, sorted bool) []*models.Catalog {
 	out := make([]*models.Catalog, 0, len(ids))
 	for _, id := range ids {
 		p, ok := m.catalog.data[id]
@@ -111,7 +172,7 @@ func (m *memStore) GetCatalogByCategoryAndQuery(category string, query string, s
 		ids = append(ids, key)
 	}
 
-	refs := m.GetCatalogs(ids, sorted)
+	refs := m.catalogsByIDs(ids, sorted)
 	var filtered []*models.Catalog
 
 	if query != "" {
@@ -135,6 +196,56 @@ func (m *memStore) RemoveCatalog(id string) {
 		return
 	}
 
-	delete(m.catalog.data, id)
+	m.drop(id)
 	delete(m.catalog.category[findedItem.Category], id)
 }
+
+// put stores the catalog and updates indexes. The caller holds the write lock.
+func (m *memStore) put(catalog *models.Catalog) {
+	id := catalog.ID.String()
+	if prev, ok := m.catalog.data[id]; ok {
+		m.invalidateSorted(prev.Category)
+		m.catalog.search.remove(id, prev)
+		m.catalog.names.remove(id, prev)
+		m.catalog.indexes.remove(id, prev)
+	}
+
+	m.catalog.data[id] = catalog
+	m.invalidateSorted(catalog.Category)
+	m.catalog.search.add(id, catalog)
+	m.catalog.names.add(id, catalog)
+	m.catalog.indexes.add(id, catalog)
+
+	if ids, ok := m.catalog.category[catalog.Category]; ok {
+		if _, ok = ids[id]; ok {
+			ids[id] = catalog.Active
+		}
+	}
+}
+
+// drop removes the catalog from data and indexes, but not from the category. The caller holds the write lock.
+func (m *memStore) drop(id string) {
+	prev, ok := m.catalog.data[id]
+	if !ok {
+		return
+	}
+
+	delete(m.catalog.data, id)
+	m.invalidateSorted(prev.Category)
+	m.catalog.search.remove(id, prev)
+	m.catalog.names.remove(id, prev)
+	m.catalog.indexes.remove(id, prev)
+}
+
+// GetAllCatalogs returns every stored catalog.
+func (m *memStore) GetAllCatalogs() []*models.Catalog {
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	out := make([]*models.Catalog, 0, len(m.catalog.data))
+	for _, catalog := range m.catalog.data {
+		out = append(out, catalog)
+	}
+
+	return out
+}
@@ -0,0 +1,267 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchSizes are numbers of catalogs in memory storage of the benchmarks.
+var benchSizes = []int{10000, 100000}
+
+var benchWords = []string{
+	"red", "green", "blue", "black", "white", "steel", "wooden", "plastic", "glass", "paper",
+	"small", "large", "heavy", "light", "round", "square", "chair", "table", "lamp", "door",
+	"window", "shelf", "box", "bottle", "cable", "screw", "panel", "frame", "wheel", "pipe",
+}
+
+// benchStores keeps filled memory storages across benchmarks.
+var benchStores = make(map[int]*memStore)
+
+// benchCatalogs returns n catalogs in 50 categories. A tenth of catalogs is inactive.
+func benchCatalogs(n int) []*models.Catalog {
+	rnd := rand.New(rand.NewSource(1))
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	catalogs := make([]*models.Catalog, n)
+	for i := range catalogs {
+		name := make([]string, 3)
+		for j := range name {
+			name[j] = benchWords[rnd.Intn(len(benchWords))]
+		}
+
+		catalogs[i] = &models.Catalog{
+			ID:        primitive.NewObjectID(),
+			Active:    i%10 != 0,
+			Category:  fmt.Sprintf("category-%d", i%50),
+			Name:      strings.Join(name, " "),
+			Desc:      benchWords[rnd.Intn(len(benchWords))] + " " + benchWords[rnd.Intn(len(benchWords))],
+			Value:     fmt.Sprintf("value-%d", i),
+			Version:   1,
+			CreatedAt: created.Add(time.Duration(i) * time.Second),
+			UpdatedAt: created.Add(time.Duration(rnd.Intn(n)) * time.Second),
+		}
+	}
+
+	return catalogs
+}
+
+// benchStore returns memory storage with n catalogs of benchCatalogs.
+func benchStore(b *testing.B, n int) *memStore {
+	b.Helper()
+
+	if m, ok := benchStores[n]; ok {
+		return m
+	}
+
+	m := NewMemStore(context.Background())
+	m.LoadCatalogs(benchCatalogs(n))
+	benchStores[n] = m
+
+	return m
+}
+
+func TestLoadCatalogsIndexesLikeUpserts(t *testing.T) {
+	catalogs := benchCatalogs(2000)
+	parent := catalogs[0].ID
+	for _, catalog := range catalogs[1:10] {
+		catalog.ParentID = &parent
+	}
+	catalogs[5].Translations = map[string]models.Translation{"en": {Name: "oak door"}}
+
+	upserted := NewMemStore(context.Background())
+	for _, catalog := range catalogs {
+		upserted.UpsertCatalog(catalog)
+		upserted.UpsertCatalogByCategory(catalog)
+	}
+
+	// Catalogs loaded over stored ones replace them
+	loaded := NewMemStore(context.Background())
+	loaded.LoadCatalogs(catalogs[:500])
+	moved := *catalogs[7]
+	moved.Category = "moved"
+	loaded.LoadCatalogs([]*models.Catalog{&moved})
+	loaded.LoadCatalogs(catalogs)
+
+	// The moved catalog leaves its category empty like RemoveCatalog does
+	if ids, ok := loaded.catalog.category["moved"]; !ok || len(ids) != 0 {
+		t.Fatalf("moved category = %v, want empty", ids)
+	}
+	delete(loaded.catalog.category, "moved")
+
+	if !reflect.DeepEqual(loaded.catalog.data, upserted.catalog.data) {
+		t.Fatal("stored catalogs differ")
+	}
+	if !reflect.DeepEqual(loaded.catalog.category, upserted.catalog.category) {
+		t.Fatal("categories differ")
+	}
+	if !reflect.DeepEqual(loaded.catalog.indexes, upserted.catalog.indexes) {
+		t.Fatal("indexes differ")
+	}
+	if !reflect.DeepEqual(loaded.catalog.search, upserted.catalog.search) {
+		t.Fatal("search indexes differ")
+	}
+	if !reflect.DeepEqual(loaded.catalog.names, upserted.catalog.names) {
+		t.Fatal("name indexes differ")
+	}
+}
+
+func TestGetCatalogByValuePrefersActiveAndLatest(t *testing.T) {
+	m := NewMemStore(context.Background())
+	updated := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	add := func(active bool, updatedAt time.Time) *models.Catalog {
+		catalog := &models.Catalog{
+			ID:        primitive.NewObjectID(),
+			Active:    active,
+			Category:  "colors",
+			Name:      "Red",
+			Value:     "red",
+			UpdatedAt: updatedAt,
+		}
+		m.UpsertCatalog(catalog)
+		m.UpsertCatalogByCategory(catalog)
+
+		return catalog
+	}
+
+	// The result must not depend on the map iteration order, so every lookup is repeated
+	lookup := func(want *models.Catalog) {
+		t.Helper()
+
+		for i := 0; i < 20; i++ {
+			found, ok := m.GetCatalogByValue("colors", "red")
+			if !ok || found.ID != want.ID {
+				t.Fatalf("GetCatalogByValue = %v, want %s", found, want.ID.Hex())
+			}
+		}
+	}
+
+	add(false, updated)
+	latest := add(false, updated.Add(time.Hour))
+	lookup(latest)
+
+	// Of catalogs updated at the same time the greatest ID wins, IDs of new catalogs grow
+	tied := add(false, updated.Add(time.Hour))
+	lookup(tied)
+
+	active := add(true, updated)
+	add(false, updated.Add(2*time.Hour))
+	lookup(active)
+
+	if _, ok := m.GetCatalogByValue("colors", "blue"); ok {
+		t.Fatal("found a catalog of a missing value")
+	}
+}
+
+func BenchmarkLoadCatalogs(b *testing.B) {
+	for _, n := range benchSizes {
+		catalogs := benchCatalogs(n)
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewMemStore(context.Background()).LoadCatalogs(catalogs)
+			}
+		})
+	}
+}
+
+func BenchmarkFindCatalogs(b *testing.B) {
+	active := true
+	queries := []struct {
+		name string
+		q    Query
+	}{
+		{"category", Query{Category: "category-7", Active: &active, SortBy: SortByName, Limit: 50}},
+		{"all", Query{Active: &active, SortBy: SortByUpdatedAt, Desc: true, Limit: 50}},
+		{"search", Query{Category: "category-7", Search: "lamp", SortBy: SortByName, Limit: 50}},
+		{"locale", Query{Category: "category-7", SortBy: SortByName, Limit: 50, Locale: "ru"}},
+		{"deep page", Query{SortBy: SortByCreatedAt, Offset: 5000, Limit: 50}},
+		// invalidated sorts the category again on every query, as after a write
+		{"invalidated", Query{Category: "category-7", SortBy: SortByName, Limit: 50}},
+		{"missing category", Query{Category: "missing", SortBy: SortByName, Limit: 50}},
+	}
+
+	for _, n := range benchSizes {
+		for _, query := range queries {
+			q := query.q
+			invalidate := query.name == "invalidated"
+
+			b.Run(fmt.Sprintf("%s/%d", query.name, n), func(b *testing.B) {
+				m := benchStore(b, n)
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					if invalidate {
+						m.invalidateSorted(q.Category)
+					}
+					if _, _, err := m.FindCatalogs(q); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSearchCatalogs(b *testing.B) {
+	queries := []struct {
+		name string
+		q    SearchQuery
+	}{
+		{"word", SearchQuery{Text: "lamp", Limit: 20}},
+		{"words", SearchQuery{Text: "red wooden table", Limit: 20}},
+		{"prefix", SearchQuery{Text: "wood", Limit: 20}},
+		{"fuzzy", SearchQuery{Text: "wodden tabel", Fuzzy: true, Limit: 20}},
+		{"category", SearchQuery{Text: "glass", Category: "category-7", Limit: 20}},
+		{"locale", SearchQuery{Text: "steel panel", Limit: 20, Locale: "en"}},
+	}
+
+	for _, n := range benchSizes {
+		for _, query := range queries {
+			q := query.q
+
+			b.Run(fmt.Sprintf("%s/%d", query.name, n), func(b *testing.B) {
+				m := benchStore(b, n)
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					m.SearchCatalogs(q)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSuggestCatalogs(b *testing.B) {
+	prefixes := []struct {
+		name, prefix, category string
+	}{
+		{"letter", "r", ""},
+		{"word", "lamp", ""},
+		{"words", "red wooden t", ""},
+		{"category", "gl", "category-7"},
+		{"missing", "zzz", ""},
+	}
+
+	for _, n := range benchSizes {
+		for _, p := range prefixes {
+			p := p
+
+			b.Run(fmt.Sprintf("%s/%d", p.name, n), func(b *testing.B) {
+				m := benchStore(b, n)
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					m.SuggestCatalogs(p.prefix, p.category, "ru", 10)
+				}
+			})
+		}
+	}
+}
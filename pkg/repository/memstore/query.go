@@ -0,0 +1,348 @@
+package memstore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/rusrafkasimov/catalogs/internal/locale"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"golang.org/x/text/collate"
+	"sort"
+	"strings"
+)
+
+type SortField string
+
+const (
+	SortByName      SortField = "name"
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+	SortByValue     SortField = "value"
+
+	timeKeyLayout = "2006-01-02T15:04:05.000000000"
+
+	// allCategories is the cache key of catalogs of every category.
+	allCategories = "\x00all"
+)
+
+var (
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSortField = errors.New("invalid sort field")
+)
+
+// Query selects a page of catalogs. Empty Category means all categories. When Cursor is set,
+// the page starts after the cursor and Offset is ignored. Names are sorted and searched in Locale,
+// empty Locale sorts by Name in byte order.
+type Query struct {
+	Category string
+	Search   string
+	Active   *bool
+	SortBy   SortField
+	Desc     bool
+	Offset   int
+	Limit    int
+	Cursor   string
+	Locale   string
+}
+
+// Page is a result of Query. NextCursor is empty on the last page.
+type Page struct {
+	Items      []*models.Catalog
+	Total      int
+	NextCursor string
+}
+
+// cursor is the position of the last returned catalog.
+type cursor struct {
+	SortBy SortField `json:"s"`
+	Desc   bool      `json:"d"`
+	Locale string    `json:"l,omitempty"`
+	Key    string    `json:"k"`
+	ID     string    `json:"i"`
+}
+
+// sortOrder is the key of cached sorted catalogs. Only names are sorted in a locale.
+type sortOrder struct {
+	field  SortField
+	locale string
+}
+
+// sortKeyer returns keys of catalogs which compare as strings. Names in a locale are compared by their
+// collation keys, hex encoded to keep cursors valid JSON. It is not safe for concurrent use.
+type sortKeyer struct {
+	order    sortOrder
+	collator *collate.Collator
+	buf      collate.Buffer
+}
+
+// ValidSortField reports whether catalogs can be sorted by the field.
+func ValidSortField(field SortField) bool {
+	switch field {
+	case SortByName, SortByCreatedAt, SortByUpdatedAt, SortByValue:
+		return true
+	}
+
+	return false
+}
+
+// FindCatalogs returns a page of catalogs matching the query. Sorted catalogs of a category are
+// cached until the category changes, so a request only walks the cached slice.
+func (m *memStore) FindCatalogs(q Query) (Page, bool, error) {
+	if q.SortBy == "" {
+		q.SortBy = SortByName
+	}
+	if !ValidSortField(q.SortBy) {
+		return Page{}, false, ErrInvalidSortField
+	}
+
+	var after *cursor
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil || c.SortBy != q.SortBy || c.Desc != q.Desc || c.Locale != q.order().locale {
+			return Page{}, false, ErrInvalidCursor
+		}
+		after = c
+	}
+
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	if _, ok := m.catalog.category[q.Category]; q.Category != "" && !ok {
+		return Page{}, false, nil
+	}
+
+	key := q.Category
+	if key == "" {
+		key = allCategories
+	}
+	sorted := m.sortedCatalogs(key, q.order())
+	keyer := newSortKeyer(q.order())
+
+	// Walk the slice in the requested direction starting after the cursor
+	start, step := 0, 1
+	if q.Desc {
+		start, step = len(sorted)-1, -1
+	}
+	if after != nil {
+		// First catalog which is not before the cursor
+		pos := sort.Search(len(sorted), func(i int) bool {
+			return !keyer.less(sorted[i], after.Key, after.ID)
+		})
+
+		if q.Desc {
+			start = pos - 1
+		} else {
+			start = pos
+			if pos < len(sorted) && keyer.equal(sorted[pos], after.Key, after.ID) {
+				start++
+			}
+		}
+	}
+
+	skip := q.Offset
+	if after != nil {
+		skip = 0
+	}
+
+	var (
+		page Page
+		last *models.Catalog
+	)
+
+	search := strings.ToLower(q.Search)
+	if search != "" && q.Locale != "" {
+		search = strings.Join(tokenize(q.Search), " ")
+	}
+	switch {
+	case search != "":
+		for _, catalog := range sorted {
+			if q.matches(catalog, search) {
+				page.Total++
+			}
+		}
+	case q.Active == nil:
+		page.Total = len(sorted)
+	case *q.Active:
+		page.Total = len(m.catalog.indexes.active[key])
+	default:
+		page.Total = len(sorted) - len(m.catalog.indexes.active[key])
+	}
+
+	for i := start; i >= 0 && i < len(sorted); i += step {
+		catalog := sorted[i]
+		if !q.matches(catalog, search) {
+			continue
+		}
+
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		if q.Limit > 0 && len(page.Items) == q.Limit {
+			page.NextCursor = encodeCursor(keyer, q.Desc, last)
+			break
+		}
+
+		page.Items = append(page.Items, catalog)
+		last = catalog
+	}
+
+	return page, true, nil
+}
+
+// matches applies filters of the query. search is the lower-cased Search, or its folded terms
+// when the query has a locale.
+func (q Query) matches(catalog *models.Catalog, search string) bool {
+	if q.Active != nil && catalog.Active != *q.Active {
+		return false
+	}
+
+	if search == "" {
+		return true
+	}
+
+	if q.Locale == "" {
+		return strings.Contains(strings.ToLower(catalog.Name), search)
+	}
+
+	return strings.Contains(nameKey(catalog.Localized(q.Locale).Name), search) ||
+		strings.Contains(nameKey(catalog.Name), search)
+}
+
+// order returns the cache key of the query sorting.
+func (q Query) order() sortOrder {
+	if q.SortBy != SortByName {
+		return sortOrder{field: q.SortBy}
+	}
+
+	return sortOrder{field: q.SortBy, locale: q.Locale}
+}
+
+// sortedCatalogs returns catalogs of the category or allCategories sorted in ascending order.
+// The caller holds the read lock, the result must not be modified.
+func (m *memStore) sortedCatalogs(category string, order sortOrder) []*models.Catalog {
+	m.catalog.sortMu.Lock()
+	defer m.catalog.sortMu.Unlock()
+
+	byOrder, ok := m.catalog.sorted[category]
+	if !ok {
+		byOrder = make(map[sortOrder][]*models.Catalog)
+		m.catalog.sorted[category] = byOrder
+	}
+
+	if sorted, ok := byOrder[order]; ok {
+		return sorted
+	}
+
+	var sorted []*models.Catalog
+	if category == allCategories {
+		sorted = make([]*models.Catalog, 0, len(m.catalog.data))
+		for _, catalog := range m.catalog.data {
+			sorted = append(sorted, catalog)
+		}
+	} else {
+		sorted = make([]*models.Catalog, 0, len(m.catalog.category[category]))
+		for id := range m.catalog.category[category] {
+			if catalog, ok := m.catalog.data[id]; ok && catalog.Category == category {
+				sorted = append(sorted, catalog)
+			}
+		}
+	}
+
+	// Keys are computed once, collation keys are expensive
+	keyer := newSortKeyer(order)
+	keys := make(map[*models.Catalog]string, len(sorted))
+	for _, catalog := range sorted {
+		keys[catalog] = keyer.key(catalog)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if keys[sorted[i]] != keys[sorted[j]] {
+			return keys[sorted[i]] < keys[sorted[j]]
+		}
+		return sorted[i].ID.Hex() < sorted[j].ID.Hex()
+	})
+
+	byOrder[order] = sorted
+
+	return sorted
+}
+
+// invalidateSorted drops cached sorted catalogs of the categories. The caller holds the write lock.
+func (m *memStore) invalidateSorted(categories ...string) {
+	delete(m.catalog.sorted, allCategories)
+	for _, category := range categories {
+		delete(m.catalog.sorted, category)
+	}
+}
+
+func newSortKeyer(order sortOrder) *sortKeyer {
+	k := &sortKeyer{order: order}
+	if order.locale != "" {
+		k.collator = locale.Collator(order.locale)
+	}
+
+	return k
+}
+
+// key returns the value of the field which compares as a string. Times are formatted
+// in UTC with a fixed width.
+func (k *sortKeyer) key(catalog *models.Catalog) string {
+	switch k.order.field {
+	case SortByCreatedAt:
+		return catalog.CreatedAt.UTC().Format(timeKeyLayout)
+	case SortByUpdatedAt:
+		return catalog.UpdatedAt.UTC().Format(timeKeyLayout)
+	case SortByValue:
+		return catalog.Value
+	}
+
+	if k.collator == nil {
+		return catalog.Name
+	}
+
+	k.buf.Reset()
+	return hex.EncodeToString(k.collator.KeyFromString(&k.buf, catalog.Localized(k.order.locale).Name))
+}
+
+// less reports whether the catalog goes before the key and ID. Equal keys are ordered by ID.
+func (k *sortKeyer) less(catalog *models.Catalog, key, id string) bool {
+	if ck := k.key(catalog); ck != key {
+		return ck < key
+	}
+
+	return catalog.ID.Hex() < id
+}
+
+// equal reports whether the catalog has the key and ID.
+func (k *sortKeyer) equal(catalog *models.Catalog, key, id string) bool {
+	return catalog.ID.Hex() == id && k.key(catalog) == key
+}
+
+func encodeCursor(keyer *sortKeyer, desc bool, catalog *models.Catalog) string {
+	data, _ := json.Marshal(&cursor{
+		SortBy: keyer.order.field,
+		Desc:   desc,
+		Locale: keyer.order.locale,
+		Key:    keyer.key(catalog),
+		ID:     catalog.ID.Hex(),
+	})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cursor{}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,385 @@
+package memstore
+
+import (
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"golang.org/x/text/cases"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Fields of a catalog in the search index.
+const (
+	fieldName uint8 = 1 << iota
+	fieldValue
+	fieldDesc
+)
+
+// Relevance of a term match, multiplied by the weight of the matched field.
+const (
+	exactScore  = 1.0
+	prefixScore = 0.6
+	fuzzyScore  = 0.4
+
+	// otherLocaleFactor lowers the score of a term found only in texts of other locales.
+	otherLocaleFactor = 0.5
+
+	defaultSearchLimit = 20
+)
+
+// SearchQuery selects catalogs matching every term of Text in name, value or desc in any locale.
+// Empty Category means all categories. Matches in Locale rank higher than in other locales.
+type SearchQuery struct {
+	Text     string
+	Category string
+	Active   *bool
+	Fuzzy    bool
+	Limit    int
+	Locale   string
+}
+
+// SearchResult is a found catalog and its relevance.
+type SearchResult struct {
+	Catalog *models.Catalog
+	Score   float64
+}
+
+// fieldText is a text of a catalog field.
+type fieldText struct {
+	field uint8
+	text  string
+}
+
+// searchIndex is an inverted index of folded terms of catalogs. It is guarded by the catalog lock.
+type searchIndex struct {
+	// postings maps term to catalog ID and the fields containing the term
+	postings map[string]map[string]uint8
+	// terms are sorted for prefix lookup
+	terms []string
+}
+
+func newSearchIndex() searchIndex {
+	return searchIndex{postings: make(map[string]map[string]uint8)}
+}
+
+// add indexes terms of the catalog.
+func (s *searchIndex) add(id string, catalog *models.Catalog) {
+	for _, term := range s.post(id, catalog) {
+		i := sort.SearchStrings(s.terms, term)
+		s.terms = append(s.terms, "")
+		copy(s.terms[i+1:], s.terms[i:])
+		s.terms[i] = term
+	}
+}
+
+// addAll indexes terms of catalogs which are not indexed yet and sorts terms once.
+func (s *searchIndex) addAll(catalogs []*models.Catalog) {
+	for _, catalog := range catalogs {
+		s.terms = append(s.terms, s.post(catalog.ID.String(), catalog)...)
+	}
+
+	sort.Strings(s.terms)
+}
+
+// post adds the catalog to postings of its terms and returns the terms new to the index.
+func (s *searchIndex) post(id string, catalog *models.Catalog) []string {
+	var added []string
+	for _, f := range searchFields(catalog) {
+		for _, term := range tokenize(f.text) {
+			posting, ok := s.postings[term]
+			if !ok {
+				posting = make(map[string]uint8)
+				s.postings[term] = posting
+				added = append(added, term)
+			}
+			posting[id] |= f.field
+		}
+	}
+
+	return added
+}
+
+// remove drops terms of the catalog from the index.
+func (s *searchIndex) remove(id string, catalog *models.Catalog) {
+	for _, f := range searchFields(catalog) {
+		for _, term := range tokenize(f.text) {
+			posting, ok := s.postings[term]
+			if !ok {
+				continue
+			}
+
+			delete(posting, id)
+			if len(posting) > 0 {
+				continue
+			}
+
+			delete(s.postings, term)
+			if i := sort.SearchStrings(s.terms, term); i < len(s.terms) && s.terms[i] == term {
+				s.terms = append(s.terms[:i], s.terms[i+1:]...)
+			}
+		}
+	}
+}
+
+// match returns IDs of catalogs containing the term, its prefix or, when fuzzy is set, a term with
+// a typo, and the best score of each catalog.
+func (s *searchIndex) match(term string, fuzzy bool) map[string]float64 {
+	scores := make(map[string]float64)
+	collect := func(indexed string, score float64) {
+		for id, fields := range s.postings[indexed] {
+			if weighted := score * fieldWeight(fields); weighted > scores[id] {
+				scores[id] = weighted
+			}
+		}
+	}
+
+	collect(term, exactScore)
+
+	for i := sort.SearchStrings(s.terms, term); i < len(s.terms) && strings.HasPrefix(s.terms[i], term); i++ {
+		if s.terms[i] != term {
+			collect(s.terms[i], prefixScore)
+		}
+	}
+
+	maxDistance := typoLimit(term)
+	if !fuzzy || maxDistance == 0 {
+		return scores
+	}
+
+	runes := []rune(term)
+	for _, indexed := range s.terms {
+		if indexed == term || strings.HasPrefix(indexed, term) {
+			continue
+		}
+		if distance := editDistance(runes, []rune(indexed), maxDistance); distance <= maxDistance {
+			collect(indexed, fuzzyScore/float64(distance))
+		}
+	}
+
+	return scores
+}
+
+// SearchCatalogs returns catalogs matching every term of the query, most relevant first.
+func (m *memStore) SearchCatalogs(q SearchQuery) []SearchResult {
+	terms := tokenize(q.Text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchLimit
+	}
+
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	// Catalogs matching every term, with scores of each term
+	termScores := make([]map[string]float64, len(terms))
+	var found map[string]bool
+	for i, term := range terms {
+		termScores[i] = m.catalog.search.match(term, q.Fuzzy)
+		if found == nil {
+			found = make(map[string]bool, len(termScores[i]))
+			for id := range termScores[i] {
+				found[id] = true
+			}
+			continue
+		}
+
+		for id := range found {
+			if _, ok := termScores[i][id]; !ok {
+				delete(found, id)
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(found))
+	for id := range found {
+		catalog, ok := m.catalog.data[id]
+		if !ok {
+			continue
+		}
+		if q.Category != "" && catalog.Category != q.Category {
+			continue
+		}
+		if q.Active != nil && catalog.Active != *q.Active {
+			continue
+		}
+
+		var localized []fieldText
+		if q.Locale != "" {
+			localized = localizedFields(catalog, q.Locale)
+		}
+
+		var score float64
+		for i, term := range terms {
+			termScore := termScores[i][id]
+			if localized != nil {
+				if local := scoreTerm(term, localized, q.Fuzzy); local > 0 {
+					termScore = local
+				} else {
+					termScore *= otherLocaleFactor
+				}
+			}
+			score += termScore
+		}
+
+		results = append(results, SearchResult{Catalog: catalog, Score: score / float64(len(terms))})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		iName, jName := results[i].Catalog.Localized(q.Locale).Name, results[j].Catalog.Localized(q.Locale).Name
+		if iName != jName {
+			return iName < jName
+		}
+		return results[i].Catalog.ID.Hex() < results[j].Catalog.ID.Hex()
+	})
+
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+
+	return results
+}
+
+// searchFields returns texts of the catalog in every locale.
+func searchFields(catalog *models.Catalog) []fieldText {
+	fields := []fieldText{
+		{fieldName, catalog.Name},
+		{fieldValue, catalog.Value},
+		{fieldDesc, catalog.Desc},
+	}
+	for _, translation := range catalog.Translations {
+		fields = append(fields, fieldText{fieldName, translation.Name}, fieldText{fieldDesc, translation.Desc})
+	}
+
+	return fields
+}
+
+// localizedFields returns texts of the catalog in the locale.
+func localizedFields(catalog *models.Catalog, locale string) []fieldText {
+	localized := catalog.Localized(locale)
+
+	return []fieldText{
+		{fieldName, localized.Name},
+		{fieldValue, catalog.Value},
+		{fieldDesc, localized.Desc},
+	}
+}
+
+// scoreTerm returns the best weighted score of the term in the texts the same way as the index does.
+func scoreTerm(term string, fields []fieldText, fuzzy bool) float64 {
+	maxDistance := 0
+	if fuzzy {
+		maxDistance = typoLimit(term)
+	}
+	runes := []rune(term)
+
+	var best float64
+	for _, f := range fields {
+		for _, token := range tokenize(f.text) {
+			var score float64
+			switch {
+			case token == term:
+				score = exactScore
+			case strings.HasPrefix(token, term):
+				score = prefixScore
+			case maxDistance > 0:
+				if distance := editDistance(runes, []rune(token), maxDistance); distance <= maxDistance {
+					score = fuzzyScore / float64(distance)
+				}
+			}
+
+			if weighted := score * fieldWeight(f.field); weighted > best {
+				best = weighted
+			}
+		}
+	}
+
+	return best
+}
+
+// fieldWeight returns the weight of the most important of the fields.
+func fieldWeight(fields uint8) float64 {
+	switch {
+	case fields&fieldName != 0:
+		return 3
+	case fields&fieldValue != 0:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// tokenize splits the text to case folded terms of letters and digits.
+func tokenize(text string) []string {
+	// The caser keeps state, so it is not shared between goroutines
+	folded := cases.Fold().String(text)
+	folded = strings.ReplaceAll(folded, "ё", "е")
+
+	return strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// typoLimit returns the number of typos allowed in the term.
+func typoLimit(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b, or max+1 when it exceeds max.
+func editDistance(a, b []rune, max int) int {
+	if diff := len(a) - len(b); diff > max || -diff > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+
+		if rowMin > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+
+	return min
+}
@@ -0,0 +1,100 @@
+package memstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const snapshotFormat = 1
+
+var errSnapshotCorrupt = errors.New("snapshot is corrupt")
+
+// Snapshot is the memory storage content with the last replication sequence applied to it.
+type Snapshot struct {
+	Sequence  uint64
+	CreatedAt time.Time
+	Catalogs  []*models.Catalog
+}
+
+// snapshotFile is the file layout: the encoded snapshot and its checksum.
+type snapshotFile struct {
+	Format   int
+	Checksum []byte
+	Payload  []byte
+}
+
+// WriteSnapshot writes the snapshot to path. The file is replaced atomically, so a crash during
+// writing keeps the previous snapshot.
+func WriteSnapshot(path string, snapshot *Snapshot) error {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	sum := sha256.Sum256(payload.Bytes())
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = gob.NewEncoder(tmp).Encode(&snapshotFile{
+		Format:   snapshotFormat,
+		Checksum: sum[:],
+		Payload:  payload.Bytes(),
+	})
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadSnapshot reads the snapshot from path and verifies its checksum.
+func ReadSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot file: %w", err)
+	}
+	defer f.Close()
+
+	var file snapshotFile
+	if err = gob.NewDecoder(f).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %s", errSnapshotCorrupt, err.Error())
+	}
+
+	if file.Format != snapshotFormat {
+		return nil, fmt.Errorf("%w: unsupported format %d", errSnapshotCorrupt, file.Format)
+	}
+
+	sum := sha256.Sum256(file.Payload)
+	if !bytes.Equal(sum[:], file.Checksum) {
+		return nil, fmt.Errorf("%w: checksum mismatch", errSnapshotCorrupt)
+	}
+
+	snapshot := &Snapshot{}
+	if err = gob.NewDecoder(bytes.NewReader(file.Payload)).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("%w: %s", errSnapshotCorrupt, err.Error())
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,171 @@
+package memstore
+
+import (
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"sort"
+	"strings"
+)
+
+const defaultSuggestLimit = 10
+
+// nameEntry is a catalog in the name index, key is the folded name.
+type nameEntry struct {
+	key     string
+	id      string
+	catalog *models.Catalog
+}
+
+// nameIndex keeps catalogs of each category and of allCategories sorted by folded name,
+// so catalogs with a name prefix are found by binary search. A catalog has an entry for each of its
+// distinct names in all locales. It is guarded by the catalog lock.
+type nameIndex map[string][]nameEntry
+
+// add inserts the catalog to its category and to allCategories.
+func (n nameIndex) add(id string, catalog *models.Catalog) {
+	for _, key := range nameKeys(catalog) {
+		entry := nameEntry{key: key, id: id, catalog: catalog}
+		for _, category := range []string{allCategories, catalog.Category} {
+			entries := n[category]
+			i := entry.search(entries)
+			entries = append(entries, nameEntry{})
+			copy(entries[i+1:], entries[i:])
+			entries[i] = entry
+			n[category] = entries
+		}
+	}
+}
+
+// addAll adds catalogs which are not indexed yet and sorts each changed category once.
+func (n nameIndex) addAll(catalogs []*models.Catalog) {
+	changed := make(map[string]bool)
+	for _, catalog := range catalogs {
+		id := catalog.ID.String()
+		for _, key := range nameKeys(catalog) {
+			entry := nameEntry{key: key, id: id, catalog: catalog}
+			for _, category := range []string{allCategories, catalog.Category} {
+				n[category] = append(n[category], entry)
+				changed[category] = true
+			}
+		}
+	}
+
+	for category := range changed {
+		entries := n[category]
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].less(entries[j])
+		})
+	}
+}
+
+// remove deletes the catalog from its category and from allCategories.
+func (n nameIndex) remove(id string, catalog *models.Catalog) {
+	for _, key := range nameKeys(catalog) {
+		entry := nameEntry{key: key, id: id}
+		for _, category := range []string{allCategories, catalog.Category} {
+			entries := n[category]
+			i := entry.search(entries)
+			if i == len(entries) || entries[i].id != id || entries[i].key != key {
+				continue
+			}
+
+			entries = append(entries[:i], entries[i+1:]...)
+			if len(entries) == 0 {
+				delete(n, category)
+				continue
+			}
+			n[category] = entries
+		}
+	}
+}
+
+// search returns the position of the entry in the sorted entries.
+func (e nameEntry) search(entries []nameEntry) int {
+	return sort.Search(len(entries), func(i int) bool {
+		if entries[i].key != e.key {
+			return entries[i].key > e.key
+		}
+		return entries[i].id >= e.id
+	})
+}
+
+// less reports whether the entry goes before the other one.
+func (e nameEntry) less(other nameEntry) bool {
+	if e.key != other.key {
+		return e.key < other.key
+	}
+	return e.id < other.id
+}
+
+// SuggestCatalogs returns up to limit active catalogs with a name in any locale starting with the prefix,
+// in alphabetical order of the matched names. Catalogs have distinct names in the locale.
+// Empty category means all categories.
+func (m *memStore) SuggestCatalogs(prefix, category, locale string, limit int) []*models.Catalog {
+	key := nameKey(prefix)
+	if key == "" {
+		return nil
+	}
+
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	if category == "" {
+		category = allCategories
+	}
+
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	entries := m.catalog.names[category]
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].key >= key
+	})
+
+	var (
+		found []*models.Catalog
+		seen  = make(map[string]bool)
+		names = make(map[string]bool)
+	)
+	for ; i < len(entries) && len(found) < limit && strings.HasPrefix(entries[i].key, key); i++ {
+		entry := entries[i]
+		if !entry.catalog.Active || seen[entry.id] {
+			continue
+		}
+		seen[entry.id] = true
+
+		name := nameKey(entry.catalog.Localized(locale).Name)
+		if names[name] {
+			continue
+		}
+		names[name] = true
+
+		found = append(found, entry.catalog)
+	}
+
+	return found
+}
+
+// nameKeys returns distinct keys of the names of the catalog in all locales.
+func nameKeys(catalog *models.Catalog) []string {
+	keys := []string{nameKey(catalog.Name)}
+	for _, translation := range catalog.Translations {
+		key := nameKey(translation.Name)
+		if key == "" {
+			continue
+		}
+
+		duplicate := false
+		for _, k := range keys {
+			duplicate = duplicate || k == key
+		}
+		if !duplicate {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// nameKey folds the name the same way as search terms, keeping words separated by one space.
+func nameKey(name string) string {
+	return strings.Join(tokenize(name), " ")
+}
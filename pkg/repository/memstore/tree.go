@@ -0,0 +1,105 @@
+package memstore
+
+import (
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"sort"
+)
+
+// TreeNode is a catalog with its children sorted by name.
+type TreeNode struct {
+	Catalog  *models.Catalog
+	Children []*TreeNode
+}
+
+// GetSubtree returns the catalog and its descendants up to depth levels below it, zero depth means
+// the whole subtree. Inactive descendants are skipped when activeOnly is set.
+func (m *memStore) GetSubtree(id string, depth int, activeOnly bool) (*TreeNode, bool) {
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	catalog, ok := m.catalog.data[id]
+	if !ok {
+		return nil, false
+	}
+
+	visited := map[string]bool{id: true}
+	root := &TreeNode{Catalog: catalog}
+
+	level := []*TreeNode{root}
+	for d := 1; len(level) > 0 && (depth == 0 || d <= depth); d++ {
+		var next []*TreeNode
+		for _, node := range level {
+			for _, child := range m.children(node.Catalog.ID.String(), activeOnly) {
+				// Replication may briefly show a cycle while moves are applied
+				if visited[child.ID.String()] {
+					continue
+				}
+				visited[child.ID.String()] = true
+
+				childNode := &TreeNode{Catalog: child}
+				node.Children = append(node.Children, childNode)
+				next = append(next, childNode)
+			}
+		}
+		level = next
+	}
+
+	return root, true
+}
+
+// GetAncestors returns ancestors of the catalog from the root to its parent. When activeOnly is set,
+// an inactive catalog is not found and inactive ancestors are skipped.
+func (m *memStore) GetAncestors(id string, activeOnly bool) ([]*models.Catalog, bool) {
+	m.catalog.RLock()
+	defer m.catalog.RUnlock()
+
+	catalog, ok := m.catalog.data[id]
+	if !ok || (activeOnly && !catalog.Active) {
+		return nil, false
+	}
+
+	var ancestors []*models.Catalog
+	visited := map[string]bool{id: true}
+	for catalog.ParentID != nil {
+		parentID := catalog.ParentID.String()
+		parent, ok := m.catalog.data[parentID]
+		if !ok || visited[parentID] {
+			break
+		}
+		visited[parentID] = true
+
+		if parent.Active || !activeOnly {
+			ancestors = append(ancestors, parent)
+		}
+		catalog = parent
+	}
+
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+
+	return ancestors, true
+}
+
+// children returns children of the catalog sorted by name. The caller holds the read lock.
+func (m *memStore) children(id string, activeOnly bool) []*models.Catalog {
+	ids := m.catalog.indexes.children[id]
+
+	out := make([]*models.Catalog, 0, len(ids))
+	for childID := range ids {
+		child, ok := m.catalog.data[childID]
+		if !ok || (activeOnly && !child.Active) {
+			continue
+		}
+		out = append(out, child)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].ID.Hex() < out[j].ID.Hex()
+	})
+
+	return out
+}
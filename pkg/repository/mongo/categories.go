@@ -0,0 +1,435 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"time"
+)
+
+const (
+	categoriesCollection = "catalog_categories"
+
+	// maxTreeDepth limits walks up catalog and category trees.
+	maxTreeDepth = 64
+)
+
+var (
+	// ErrOrphan is returned when the parent catalog or category does not exist.
+	ErrOrphan = errors.New("parent not found")
+	// ErrCycle is returned when the parent is the item itself or one of its descendants.
+	ErrCycle = errors.New("parent makes a cycle")
+	// ErrParentCategory is returned when the parent catalog is not of the category or its parent category.
+	ErrParentCategory = errors.New("parent catalog belongs to another category")
+	// ErrHasChildren is returned when a catalog with children is purged.
+	ErrHasChildren = errors.New("catalog has children")
+
+	// ErrCategoryNotFound is returned when the category has no metadata.
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrCategoryExists is returned when metadata of the category is created twice.
+	ErrCategoryExists = errors.New("category already exists")
+	// ErrCategoryInUse is returned when a category with catalogs or child categories is deleted.
+	ErrCategoryInUse = errors.New("category has catalogs or child categories")
+	// ErrInvalidSchema is returned when the value schema of the category can't be compiled.
+	ErrInvalidSchema = errors.New("invalid value schema")
+	// ErrSchemaViolation is returned when the value of a catalog is not allowed by its category.
+	ErrSchemaViolation = errors.New("value violates category schema")
+)
+
+type CategoriesRepository interface {
+	FindCategories(ctx context.Context, span opentracing.Span) ([]*models.Category, error)
+	FindCategory(ctx context.Context, name string, span opentracing.Span) (*models.Category, error)
+	CreateCategory(ctx context.Context, category *models.Category, span opentracing.Span) (*models.Category, error)
+	UpdateCategory(ctx context.Context, category *models.Category, span opentracing.Span) (*models.Category, error)
+	DeleteCategory(ctx context.Context, name string, span opentracing.Span) error
+	SetCategoryParent(ctx context.Context, name, parent string, span opentracing.Span) (*models.Category, error)
+}
+
+// CategoriesRepo stores metadata of categories.
+type CategoriesRepo struct {
+	db     ClientProvider
+	logger promtail.Client
+}
+
+func NewCategoriesRepository(db ClientProvider, logger promtail.Client) *CategoriesRepo {
+	return &CategoriesRepo{db, logger}
+}
+
+// collection returns categories collection of the current client.
+func (m *CategoriesRepo) collection() *mongo.Collection {
+	return m.db.Client().Database(mgoDatabase).Collection(categoriesCollection)
+}
+
+// FindCategories returns metadata of all categories sorted by name.
+func (m *CategoriesRepo) FindCategories(ctx context.Context, span opentracing.Span) ([]*models.Category, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:FindCategories", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	cursor, err := m.collection().Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, err
+	}
+
+	categories := make([]*models.Category, 0)
+	if err = cursor.All(ctx, &categories); err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// FindCategory returns metadata of the category.
+func (m *CategoriesRepo) FindCategory(ctx context.Context, name string, span opentracing.Span) (*models.Category, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:FindCategory", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	var category models.Category
+	if err := m.collection().FindOne(ctx, bson.M{"_id": name}).Decode(&category); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			err = fmt.Errorf("category %q: %w", name, ErrCategoryNotFound)
+		}
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// CreateCategory stores metadata of a new category. Catalogs may already refer to the category,
+// so their values are checked against the schema.
+func (m *CategoriesRepo) CreateCategory(ctx context.Context, category *models.Category, span opentracing.Span) (*models.Category, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:CreateCategory", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	category.CreatedAt = time.Now().UTC()
+	category.UpdatedAt = category.CreatedAt
+
+	err := withTransaction(ctx, m.db, func(sc mongo.SessionContext) error {
+		if err := m.validateCategory(sc, category); err != nil {
+			return err
+		}
+
+		if _, err := m.collection().InsertOne(sc, category); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("category %q: %w", category.Name, ErrCategoryExists)
+			}
+			return fmt.Errorf("failed to insert one: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// UpdateCategory replaces metadata of the category. Values of its catalogs are checked against the new schema.
+func (m *CategoriesRepo) UpdateCategory(ctx context.Context, category *models.Category, span opentracing.Span) (*models.Category, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:UpdateCategory", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	err := withTransaction(ctx, m.db, func(sc mongo.SessionContext) error {
+		current, err := findCategory(sc, category.Name)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return fmt.Errorf("category %q: %w", category.Name, ErrCategoryNotFound)
+		}
+
+		if err = m.validateCategory(sc, category); err != nil {
+			return err
+		}
+
+		category.CreatedAt = current.CreatedAt
+		category.UpdatedAt = time.Now().UTC()
+		category.Revision = current.Revision
+		if _, err = m.collection().ReplaceOne(sc, bson.M{"_id": category.Name}, category); err != nil {
+			return fmt.Errorf("failed to replace one: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory deletes metadata of the category. Categories with catalogs or child categories are kept.
+func (m *CategoriesRepo) DeleteCategory(ctx context.Context, name string, span opentracing.Span) error {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:DeleteCategory", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	err := withTransaction(ctx, m.db, func(sc mongo.SessionContext) error {
+		database := sc.Client().Database(mgoDatabase)
+
+		for _, query := range []struct {
+			collection string
+			filter     bson.M
+		}{
+			{companyCollection, bson.M{"category": name}},
+			{categoriesCollection, bson.M{"parent": name}},
+		} {
+			count, err := database.Collection(query.collection).CountDocuments(sc, query.filter, options.Count().SetLimit(1))
+			if err != nil {
+				return fmt.Errorf("failed to count documents: %w", err)
+			}
+			if count > 0 {
+				return fmt.Errorf("category %q: %w", name, ErrCategoryInUse)
+			}
+		}
+
+		res, err := m.collection().DeleteOne(sc, bson.M{"_id": name})
+		if err != nil {
+			return fmt.Errorf("failed to delete one: %w", err)
+		}
+		if res.DeletedCount == 0 {
+			return fmt.Errorf("category %q: %w", name, ErrCategoryNotFound)
+		}
+
+		return nil
+	})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return err
+	}
+
+	return nil
+}
+
+// SetCategoryParent stores the parent of the category, empty parent makes it a root. The parent
+// must be a known category and must not be a descendant of the category. Other metadata is kept.
+func (m *CategoriesRepo) SetCategoryParent(ctx context.Context, name, parent string, span opentracing.Span) (*models.Category, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:SetCategoryParent", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	var category *models.Category
+
+	err := withTransaction(ctx, m.db, func(sc mongo.SessionContext) error {
+		if parent != "" {
+			if err := m.validateParent(sc, name, parent); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		update := bson.M{
+			"$set":         bson.M{"parent": parent, "updated_at": now},
+			"$setOnInsert": bson.M{"created_at": now, "sort_order": 0},
+		}
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+		category = &models.Category{}
+		if err := m.collection().FindOneAndUpdate(sc, bson.M{"_id": name}, update, opts).Decode(category); err != nil {
+			return fmt.Errorf("failed to update one: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// validateCategory checks the parent of the category, its schema and values of its catalogs.
+func (m *CategoriesRepo) validateCategory(sc mongo.SessionContext, category *models.Category) error {
+	if category.Parent != "" {
+		if err := m.validateParent(sc, category.Name, category.Parent); err != nil {
+			return err
+		}
+	}
+
+	if category.Schema == nil {
+		return nil
+	}
+
+	validate, err := category.Schema.Compile()
+	if err != nil {
+		return fmt.Errorf("category %q: %v: %w", category.Name, err, ErrInvalidSchema)
+	}
+
+	// Archived catalogs can't be updated, so they keep values allowed by the previous schema
+	filter := bson.M{"category": category.Name, "status": bson.M{"$ne": models.StatusArchived}}
+	cursor, err := sc.Client().Database(mgoDatabase).Collection(companyCollection).Find(sc, filter,
+		options.Find().SetProjection(bson.M{"value": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to find: %w", err)
+	}
+	defer cursor.Close(sc)
+
+	for cursor.Next(sc) {
+		var catalog models.Catalog
+		if err = cursor.Decode(&catalog); err != nil {
+			return fmt.Errorf("failed to decode catalog: %w", err)
+		}
+
+		if err = validate(catalog.Value); err != nil {
+			return fmt.Errorf("catalog %s: %v: %w", catalog.ID.Hex(), err, ErrSchemaViolation)
+		}
+	}
+
+	return cursor.Err()
+}
+
+// validateParent walks up from the parent category and fails when it reaches the category.
+func (m *CategoriesRepo) validateParent(sc mongo.SessionContext, name, parent string) error {
+	exists, err := categoryExists(sc, parent)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("category %q: %w", parent, ErrOrphan)
+	}
+
+	for current, depth := parent, 0; current != ""; depth++ {
+		if current == name || depth == maxTreeDepth {
+			return fmt.Errorf("category %q: %w", parent, ErrCycle)
+		}
+
+		current, err = parentCategory(sc, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// categoryExists reports whether the category has metadata or catalogs.
+func categoryExists(sc mongo.SessionContext, name string) (bool, error) {
+	database := sc.Client().Database(mgoDatabase)
+
+	for _, query := range []struct {
+		collection string
+		filter     bson.M
+	}{
+		{categoriesCollection, bson.M{"_id": name}},
+		{companyCollection, bson.M{"category": name}},
+	} {
+		count, err := database.Collection(query.collection).CountDocuments(sc, query.filter, options.Count().SetLimit(1))
+		if err != nil {
+			return false, fmt.Errorf("failed to count documents: %w", err)
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parentCategory returns the parent of the category, empty for roots and categories without metadata.
+func parentCategory(sc mongo.SessionContext, name string) (string, error) {
+	category, err := findCategory(sc, name)
+	if err != nil || category == nil {
+		return "", err
+	}
+
+	return category.Parent, nil
+}
+
+// findCategory returns metadata of the category, nil when there is none.
+func findCategory(sc mongo.SessionContext, name string) (*models.Category, error) {
+	var category models.Category
+
+	err := sc.Client().Database(mgoDatabase).Collection(categoriesCollection).FindOne(sc, bson.M{"_id": name}).Decode(&category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find one: %w", err)
+	}
+
+	return &category, nil
+}
+
+// validatorCache keeps compiled validators of category schemas. A validator is compiled again when
+// the category is updated.
+type validatorCache struct {
+	mu         sync.Mutex
+	validators map[string]cachedValidator
+}
+
+// cachedValidator is the validator of the schema of the category updated at updatedAt.
+type cachedValidator struct {
+	updatedAt time.Time
+	validate  models.ValueValidator
+}
+
+func newValidatorCache() *validatorCache {
+	return &validatorCache{validators: make(map[string]cachedValidator)}
+}
+
+// validator returns the validator of the schema of the category, compiling it when the category changed.
+func (c *validatorCache) validator(category *models.Category) (models.ValueValidator, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.validators[category.Name]; ok && cached.updatedAt.Equal(category.UpdatedAt) {
+		return cached.validate, nil
+	}
+
+	validate, err := category.Schema.Compile()
+	if err != nil {
+		return nil, err
+	}
+	c.validators[category.Name] = cachedValidator{updatedAt: category.UpdatedAt, validate: validate}
+
+	return validate, nil
+}
+
+// validateValue checks the value of the catalog against the schema of its category. The revision of
+// the category is bumped, so a concurrent schema change checking values of the category conflicts
+// with the write of the catalog and one of the transactions is retried.
+func (m *CatalogsRepo) validateValue(sc mongo.SessionContext, catalog *models.Catalog) error {
+	category, err := findCategory(sc, catalog.Category)
+	if err != nil || category == nil {
+		return err
+	}
+
+	_, err = sc.Client().Database(mgoDatabase).Collection(categoriesCollection).UpdateOne(sc,
+		bson.M{"_id": category.Name}, bson.M{"$inc": bson.M{"revision": 1}})
+	if err != nil {
+		return fmt.Errorf("failed to update category revision: %w", err)
+	}
+
+	if category.Schema == nil {
+		return nil
+	}
+
+	validate, err := m.validators.validator(category)
+	if err != nil {
+		return fmt.Errorf("category %q: %v: %w", category.Name, err, ErrInvalidSchema)
+	}
+
+	if err = validate(catalog.Value); err != nil {
+		return fmt.Errorf("category %q: %v: %w", category.Name, err, ErrSchemaViolation)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestValidatorCacheCompilesChangedCategories(t *testing.T) {
+	cache := newValidatorCache()
+	updated := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	category := &models.Category{
+		Name:      "sizes",
+		Schema:    &models.ValueSchema{Type: models.SchemaEnum, Enum: []string{"small"}},
+		UpdatedAt: updated,
+	}
+	validate, err := cache.validator(category)
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	if validate("small") != nil || validate("large") == nil {
+		t.Fatal("validator does not follow the schema")
+	}
+
+	// The cached validator is kept while the category is not updated
+	same := *category
+	same.Schema = &models.ValueSchema{Type: models.SchemaEnum, Enum: []string{"large"}}
+	if validate, _ = cache.validator(&same); validate("small") != nil {
+		t.Fatal("validator of an unchanged category was compiled again")
+	}
+
+	changed := same
+	changed.UpdatedAt = updated.Add(time.Second)
+	if validate, _ = cache.validator(&changed); validate("large") != nil || validate("small") == nil {
+		t.Fatal("validator of an updated category was not compiled again")
+	}
+
+	// Invalid schemas are not cached
+	broken := changed
+	broken.UpdatedAt = updated.Add(2 * time.Second)
+	broken.Schema = &models.ValueSchema{Type: models.SchemaEnum}
+	if _, err = cache.validator(&broken); err == nil {
+		t.Fatal("invalid schema compiled")
+	}
+	if cached := cache.validators["sizes"]; !cached.updatedAt.Equal(changed.UpdatedAt) {
+		t.Fatalf("cached validator of %v, want %v", cached.updatedAt, changed.UpdatedAt)
+	}
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/rusrafkasimov/catalogs/internal/queue"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"time"
+)
+
+const (
+	resumeTokensCollection = "resume_tokens"
+
+	// Server error codes returned when the resume token is no longer in the oplog.
+	errCodeChangeStreamHistoryLost = 286
+	errCodeChangeStreamFatal       = 280
+)
+
+var errChangeStreamClosed = errors.New("change stream is closed")
+
+// changeEvent is a change stream document of the catalogs collection.
+type changeEvent struct {
+	ID            bson.Raw            `bson:"_id"`
+	OperationType string              `bson:"operationType"`
+	ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+	FullDocument  *models.Catalog     `bson:"fullDocument"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+}
+
+// streamEvent is a replication event read from the change stream. Ack persists its resume token.
+type streamEvent struct {
+	opn *models.Operation
+	seq uint64
+	ack func() error
+}
+
+func (e *streamEvent) Operation() *models.Operation {
+	return e.opn
+}
+
+func (e *streamEvent) Sequence() uint64 {
+	return e.seq
+}
+
+func (e *streamEvent) Ack() error {
+	return e.ack()
+}
+
+// ChangeStream is a replication source following every write to the catalogs collection, including
+// writes made around the service. The resume token of the last acknowledged event is stored in Mongo,
+// so after restart the stream continues from it. Events are delivered one by one like in the queue.
+type ChangeStream struct {
+	db               ClientProvider
+	logger           promtail.Client
+	resumeID         string
+	startAt          primitive.Timestamp
+	reconnectTimeout time.Duration
+
+	mu         sync.Mutex
+	output     chan queue.Event
+	subscribed bool
+	closed     bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewChangeStream creates a change stream source. Without a stored resume token the stream starts at
+// the creation time, so changes made while the replicator loads catalogs are not lost.
+func NewChangeStream(db ClientProvider, logger promtail.Client, resumeID string) *ChangeStream {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ChangeStream{
+		db:               db,
+		logger:           logger,
+		resumeID:         resumeID,
+		startAt:          primitive.Timestamp{T: uint32(time.Now().Unix())},
+		reconnectTimeout: time.Second,
+		output:           make(chan queue.Event),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// Subscribe starts watching the collection and returns channel with replication events.
+func (c *ChangeStream) Subscribe() (<-chan queue.Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, errChangeStreamClosed
+	}
+
+	if !c.subscribed {
+		c.subscribed = true
+		c.wg.Add(1)
+		go c.watch()
+	}
+
+	return c.output, nil
+}
+
+// Close stops watching and closes the events channel.
+func (c *ChangeStream) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.cancel()
+	c.wg.Wait()
+
+	close(c.output)
+
+	return nil
+}
+
+// CommitOrdered reports that events are delivered in commit order, their sequence is the cluster time.
+func (c *ChangeStream) CommitOrdered() bool {
+	return true
+}
+
+// tokens returns resume tokens collection of the current client.
+func (c *ChangeStream) tokens() *mongo.Collection {
+	return c.db.Client().Database(mgoDatabase).Collection(resumeTokensCollection)
+}
+
+// watch opens the change stream and reopens it after errors until the source is closed.
+func (c *ChangeStream) watch() {
+	defer c.wg.Done()
+
+	for {
+		err := c.stream()
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			trace.OnError(c.logger, nil, err)
+		}
+
+		select {
+		case <-time.After(c.reconnectTimeout):
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// stream reads events until an error happens. An expired resume token is dropped, and the stream
+// starts from the current time.
+func (c *ChangeStream) stream() error {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	token, err := c.loadToken()
+	if err != nil {
+		return err
+	}
+
+	if token != nil {
+		opts.SetResumeAfter(token)
+	} else {
+		opts.SetStartAtOperationTime(&c.startAt)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "operationType", Value: bson.D{
+			{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete"}},
+		}}}}},
+	}
+
+	cs, err := c.db.Client().Database(mgoDatabase).Collection(companyCollection).Watch(c.ctx, pipeline, opts)
+	if err != nil {
+		if isHistoryLost(err) {
+			c.logger.Warnf("Change stream resume token expired, starting from current time")
+			c.startAt = primitive.Timestamp{T: uint32(time.Now().Unix())}
+			return c.deleteToken()
+		}
+		return fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer cs.Close(context.Background())
+
+	for cs.Next(c.ctx) {
+		var change changeEvent
+		if err = cs.Decode(&change); err != nil {
+			return fmt.Errorf("failed to decode change event: %w", err)
+		}
+
+		op := changeOperation(&change)
+		if op == nil {
+			if err = c.saveToken(change.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !c.deliver(op, &change) {
+			return nil
+		}
+	}
+
+	if err = cs.Err(); err != nil && c.ctx.Err() == nil {
+		return fmt.Errorf("change stream failed: %w", err)
+	}
+
+	return nil
+}
+
+// deliver sends the event and waits for its acknowledgement, redelivering it after a timeout.
+// It returns false when the source is closed.
+func (c *ChangeStream) deliver(op *models.Operation, change *changeEvent) bool {
+	for {
+		acked := make(chan struct{})
+		var once sync.Once
+
+		evt := &streamEvent{
+			opn: op,
+			seq: uint64(change.ClusterTime.T)<<32 | uint64(change.ClusterTime.I),
+			ack: func() error {
+				err := c.saveToken(change.ID)
+				if err == nil {
+					once.Do(func() { close(acked) })
+				}
+				return err
+			},
+		}
+
+		select {
+		case c.output <- evt:
+		case <-c.ctx.Done():
+			return false
+		}
+
+		select {
+		case <-acked:
+			return true
+		case <-time.After(c.reconnectTimeout):
+		case <-c.ctx.Done():
+			return false
+		}
+	}
+}
+
+// loadToken returns the stored resume token or nil.
+func (c *ChangeStream) loadToken() (bson.Raw, error) {
+	var doc struct {
+		Token bson.Raw `bson:"token"`
+	}
+
+	err := c.tokens().FindOne(c.ctx, bson.M{"_id": c.resumeID}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load resume token: %w", err)
+	}
+
+	return doc.Token, nil
+}
+
+// saveToken stores the resume token.
+func (c *ChangeStream) saveToken(token bson.Raw) error {
+	_, err := c.tokens().UpdateOne(c.ctx, bson.M{"_id": c.resumeID}, bson.M{
+		"$set": bson.M{"token": token, "updated_at": time.Now()},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save resume token: %w", err)
+	}
+
+	return nil
+}
+
+// deleteToken removes the stored resume token.
+func (c *ChangeStream) deleteToken() error {
+	if _, err := c.tokens().DeleteOne(c.ctx, bson.M{"_id": c.resumeID}); err != nil {
+		return fmt.Errorf("failed to delete resume token: %w", err)
+	}
+
+	return nil
+}
+
+// changeOperation converts the change event to a replication operation. It returns nil when
+// an update has no document anymore, the following delete event removes it.
+func changeOperation(change *changeEvent) *models.Operation {
+	op := &models.Operation{
+		Type:      models.OperationTypeCatalogs,
+		Timestamp: time.Unix(int64(change.ClusterTime.T), 0),
+	}
+
+	switch change.OperationType {
+	case "delete":
+		op.Method = models.OperationMethodDelete
+		op.Catalog = &models.Catalog{ID: change.DocumentKey.ID}
+	default:
+		if change.FullDocument == nil {
+			return nil
+		}
+		op.Method = models.OperationMethodUpsert
+		op.Catalog = change.FullDocument
+	}
+
+	return op
+}
+
+// isHistoryLost reports whether the stream can not be resumed from the stored token.
+func isHistoryLost(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == errCodeChangeStreamHistoryLost || cmdErr.Code == errCodeChangeStreamFatal
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const deadLettersCollection = "catalogs_dead_letters"
+
+var (
+	// ErrDeadLetterNotFound is returned when there is no dead letter with the given ID.
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	// ErrNotReplayable is returned for dead letters which could not be decoded.
+	ErrNotReplayable = errors.New("dead letter has no operation to replay")
+)
+
+type DeadLettersRepository interface {
+	AddDeadLetter(ctx context.Context, deadLetter *models.DeadLetter) error
+	FindDeadLetters(ctx context.Context, limit, offset int64, span opentracing.Span) ([]*models.DeadLetter, int64, error)
+	ReplayDeadLetter(ctx context.Context, id string, span opentracing.Span) error
+	DeleteDeadLetter(ctx context.Context, id string, span opentracing.Span) error
+}
+
+// DeadLettersRepo stores replication events which failed to apply.
+type DeadLettersRepo struct {
+	db     ClientProvider
+	logger promtail.Client
+	outbox *OutboxRelay
+}
+
+func NewDeadLettersRepository(db ClientProvider, outbox *OutboxRelay, logger promtail.Client) *DeadLettersRepo {
+	return &DeadLettersRepo{db, logger, outbox}
+}
+
+// collection returns dead letters collection of the current client.
+func (m *DeadLettersRepo) collection() *mongo.Collection {
+	return m.db.Client().Database(mgoDatabase).Collection(deadLettersCollection)
+}
+
+// catalogs returns catalogs collection of the current client.
+func (m *DeadLettersRepo) catalogs() *mongo.Collection {
+	return m.db.Client().Database(mgoDatabase).Collection(companyCollection)
+}
+
+// AddDeadLetter stores the dead letter.
+func (m *DeadLettersRepo) AddDeadLetter(ctx context.Context, deadLetter *models.DeadLetter) error {
+	if deadLetter.ID.IsZero() {
+		deadLetter.ID = primitive.NewObjectID()
+	}
+
+	if _, err := m.collection().InsertOne(ctx, deadLetter); err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// FindDeadLetters returns dead letters from newest to oldest and their total count.
+func (m *DeadLettersRepo) FindDeadLetters(ctx context.Context, limit, offset int64, span opentracing.Span) ([]*models.DeadLetter, int64, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:FindDeadLetters", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	total, err := m.collection().CountDocuments(ctx, bson.D{})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	cursor, err := m.collection().Find(ctx, bson.D{}, opts)
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, 0, err
+	}
+
+	deadLetters := make([]*models.DeadLetter, 0)
+	if err = cursor.All(ctx, &deadLetters); err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, 0, err
+	}
+
+	return deadLetters, total, nil
+}
+
+// ReplayDeadLetter removes the dead letter and delivers its catalog again in one transaction. A stored
+// catalog gets the next version, so the write reaches every node through the change stream, and the
+// operation with the current catalog is written to the outbox for the event queue. The operation of
+// a catalog deleted since is written to the outbox as is, with the change stream the reconciler
+// removes such catalogs.
+func (m *DeadLettersRepo) ReplayDeadLetter(ctx context.Context, id string, span opentracing.Span) error {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:ReplayDeadLetter", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrDeadLetterNotFound
+	}
+
+	err = withOutbox(ctx, m.db, m.outbox, func(sc mongo.SessionContext) (*models.Operation, error) {
+		var deadLetter models.DeadLetter
+		if err := m.collection().FindOneAndDelete(sc, bson.M{"_id": objectID}).Decode(&deadLetter); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrDeadLetterNotFound
+			}
+			return nil, err
+		}
+
+		op := deadLetter.Operation
+		if op == nil || op.Catalog == nil {
+			return nil, ErrNotReplayable
+		}
+
+		var current models.Catalog
+		err := m.catalogs().FindOneAndUpdate(sc, bson.M{"_id": op.Catalog.ID}, bson.M{
+			"$inc": bson.M{"version": 1},
+		}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&current)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return op, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find one and update: %w", err)
+		}
+
+		return &models.Operation{
+			Type:    models.OperationTypeCatalogs,
+			Method:  models.OperationMethodUpsert,
+			Catalog: &current,
+		}, nil
+	})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteDeadLetter discards the dead letter.
+func (m *DeadLettersRepo) DeleteDeadLetter(ctx context.Context, id string, span opentracing.Span) error {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:DeleteDeadLetter", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrDeadLetterNotFound
+	}
+
+	res, err := m.collection().DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrDeadLetterNotFound
+	}
+
+	return nil
+}
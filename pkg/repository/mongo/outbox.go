@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/internal/queue"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	outboxCollection = "catalogs_outbox"
+	locksCollection  = "locks"
+	outboxLockID     = "catalogs_outbox_relay"
+	outboxMinBackoff = time.Second
+)
+
+// OutboxRelay publishes pending outbox events to the event queue in creation order and marks them
+// done, giving at-least-once delivery. Only the relay holding the lock publishes, so several
+// service nodes do not reorder events.
+type OutboxRelay struct {
+	db         ClientProvider
+	eventQueue queue.EventQueue
+	logger     promtail.Client
+	cfg        config.Outbox
+	owner      string
+	notifyCh   chan struct{}
+
+	now func() time.Time
+}
+
+func NewOutboxRelay(db ClientProvider, eventQueue queue.EventQueue, logger promtail.Client, cfg config.Outbox, owner string) *OutboxRelay {
+	return &OutboxRelay{
+		db:         db,
+		eventQueue: eventQueue,
+		logger:     logger,
+		cfg:        cfg,
+		owner:      owner,
+		notifyCh:   make(chan struct{}, 1),
+		now:        time.Now,
+	}
+}
+
+// collection returns outbox collection of the current client.
+func (r *OutboxRelay) collection() *mongo.Collection {
+	return r.db.Client().Database(mgoDatabase).Collection(outboxCollection)
+}
+
+// Notify wakes the relay up after a new event is committed.
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes outbox events until ctx is done.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	if err := r.ensureIndexes(ctx); err != nil {
+		trace.OnError(r.logger, nil, err)
+	}
+
+	tc := time.NewTicker(r.cfg.PollInterval)
+	defer tc.Stop()
+
+	for {
+		if err := r.relay(ctx); err != nil && ctx.Err() == nil {
+			trace.OnError(r.logger, nil, err)
+		}
+
+		select {
+		case <-tc.C:
+		case <-r.notifyCh:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ensureIndexes creates the index used by the relay and the TTL index for published events.
+func (r *OutboxRelay) ensureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "published_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(r.cfg.Retention.Seconds())),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create outbox indexes: %w", err)
+	}
+
+	return nil
+}
+
+// relay publishes one batch of pending events. It stops on the first failed event to keep the order.
+func (r *OutboxRelay) relay(ctx context.Context) error {
+	locked, err := r.lock(ctx)
+	if err != nil || !locked {
+		return err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(r.cfg.BatchSize))
+
+	cursor, err := r.collection().Find(ctx, bson.M{"status": models.OutboxStatusPending}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to find outbox events: %w", err)
+	}
+
+	var events []*models.OutboxEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return fmt.Errorf("failed to decode outbox events: %w", err)
+	}
+
+	for _, event := range events {
+		if event.NextAttemptAt.After(r.now()) {
+			return nil
+		}
+
+		if err = r.eventQueue.Publish(event.Operation); err != nil {
+			return r.fail(ctx, event, err)
+		}
+
+		if err = r.done(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// done marks the event published.
+func (r *OutboxRelay) done(ctx context.Context, event *models.OutboxEvent) error {
+	now := r.now()
+	_, err := r.collection().UpdateOne(ctx, bson.M{"_id": event.ID}, bson.M{
+		"$set": bson.M{"status": models.OutboxStatusDone, "published_at": now},
+		"$inc": bson.M{"attempts": 1},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event %s done: %w", event.ID.Hex(), err)
+	}
+
+	return nil
+}
+
+// fail records the publish error and schedules the next attempt with exponential backoff.
+func (r *OutboxRelay) fail(ctx context.Context, event *models.OutboxEvent, cause error) error {
+	backoff := outboxMinBackoff << uint(event.Attempts)
+	if backoff <= 0 || backoff > r.cfg.MaxBackoff {
+		backoff = r.cfg.MaxBackoff
+	}
+
+	_, err := r.collection().UpdateOne(ctx, bson.M{"_id": event.ID}, bson.M{
+		"$set": bson.M{"last_error": cause.Error(), "next_attempt_at": r.now().Add(backoff)},
+		"$inc": bson.M{"attempts": 1},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox event %s: %w", event.ID.Hex(), err)
+	}
+
+	return fmt.Errorf("failed to publish outbox event %s: %w", event.ID.Hex(), cause)
+}
+
+// lock takes or extends the relay lock. It returns false when another node holds it.
+func (r *OutboxRelay) lock(ctx context.Context) (bool, error) {
+	now := r.now()
+	filter := bson.M{
+		"_id": outboxLockID,
+		"$or": bson.A{
+			bson.M{"owner": r.owner},
+			bson.M{"expires_at": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"owner": r.owner, "expires_at": now.Add(r.cfg.LockTTL)},
+	}
+
+	locks := r.db.Client().Database(mgoDatabase).Collection(locksCollection)
+	_, err := locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+
+	return true, nil
+}
+
+// newOutboxEvent wraps the operation in a pending outbox event.
+func newOutboxEvent(op *models.Operation, now time.Time) *models.OutboxEvent {
+	return &models.OutboxEvent{
+		ID:            primitive.NewObjectID(),
+		Operation:     op,
+		Status:        models.OutboxStatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
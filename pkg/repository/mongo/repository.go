@@ -2,15 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/afiskon/promtail-client/promtail"
 	"github.com/opentracing/opentracing-go"
-	"github.com/rusrafkasimov/catalogs/internal/queue"
 	"github.com/rusrafkasimov/catalogs/internal/trace"
 	"github.com/rusrafkasimov/catalogs/pkg/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 const (
@@ -23,20 +25,90 @@ type CatalogsRepository interface {
 	FindCatalogByID(ctx context.Context, id primitive.ObjectID, span opentracing.Span) (*models.Catalog, error)
 	FindCatalogsByCategory(ctx context.Context, category string, span opentracing.Span) ([]*models.Catalog, error)
 	FindCatalogsCategories(ctx context.Context, span opentracing.Span) ([]string, error)
+	CatalogStamps(ctx context.Context, span opentracing.Span) (map[string]CategoryStamps, error)
 	UpdateCatalog(ctx context.Context, id string, model *models.Catalog, span opentracing.Span) (*models.Catalog, error)
-	DeleteCatalog(ctx context.Context, id string, span opentracing.Span) bool
+	DeleteCatalog(ctx context.Context, id string, span opentracing.Span) error
+	SetCatalogStatus(ctx context.Context, id string, status models.CatalogStatus, span opentracing.Span) (*models.Catalog, error)
+	SetCatalogTranslations(ctx context.Context, id string, translations map[string]models.Translation, replace bool, span opentracing.Span) (*models.Catalog, error)
+	PurgeCatalog(ctx context.Context, id string, span opentracing.Span) error
 }
 
-func NewCatalogsRepository(ct *mongo.Client, eventQueue queue.EventQueue, logger promtail.Client) *CatalogsRepo {
-	collection := ct.Database(mgoDatabase).Collection(companyCollection)
-	return &CatalogsRepo{ct, collection, logger, eventQueue}
+// ClientProvider returns the current mongo client. The client may be replaced when credentials change.
+type ClientProvider interface {
+	Client() *mongo.Client
+}
+
+var (
+	// ErrNotFound is returned when there is no catalog with the given ID.
+	ErrNotFound = errors.New("catalog not found")
+	// ErrInvalidTransition is returned when the catalog can't change its lifecycle state or be updated in it.
+	ErrInvalidTransition = errors.New("invalid catalog state transition")
+
+	// ErrVersionConflict is returned when the catalog was changed after the version given for update.
+	ErrVersionConflict = errors.New("catalog version conflict")
+)
+
+func NewCatalogsRepository(db ClientProvider, outbox *OutboxRelay, logger promtail.Client) *CatalogsRepo {
+	return &CatalogsRepo{db, logger, outbox, newValidatorCache()}
 }
 
 type CatalogsRepo struct {
-	conn       *mongo.Client
-	collection *mongo.Collection
+	db         ClientProvider
 	logger     promtail.Client
-	eventQueue queue.EventQueue
+	outbox     *OutboxRelay
+	validators *validatorCache
+}
+
+// collection returns catalogs collection of the current client.
+func (m *CatalogsRepo) collection() *mongo.Collection {
+	return m.db.Client().Database(mgoDatabase).Collection(companyCollection)
+}
+
+// withOutbox runs fn and writes the returned operation to the outbox in one transaction,
+// so a catalog change is never saved without its replication event.
+func (m *CatalogsRepo) withOutbox(ctx context.Context, fn func(sc mongo.SessionContext) (*models.Operation, error)) error {
+	return withOutbox(ctx, m.db, m.outbox, fn)
+}
+
+// withOutbox runs fn and writes the returned operation to the outbox in one transaction.
+func withOutbox(ctx context.Context, db ClientProvider, relay *OutboxRelay, fn func(sc mongo.SessionContext) (*models.Operation, error)) error {
+	err := withTransaction(ctx, db, func(sc mongo.SessionContext) error {
+		op, err := fn(sc)
+		if err != nil {
+			return err
+		}
+
+		outbox := sc.Client().Database(mgoDatabase).Collection(outboxCollection)
+		if _, err = outbox.InsertOne(sc, newOutboxEvent(op, time.Now())); err != nil {
+			return fmt.Errorf("failed to write outbox: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if relay != nil {
+		relay.Notify()
+	}
+
+	return nil
+}
+
+// withTransaction runs fn in a transaction of the current client.
+func withTransaction(ctx context.Context, db ClientProvider, fn func(sc mongo.SessionContext) error) error {
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+
+	return err
 }
 
 func (m *CatalogsRepo) CreateCatalog(ctx context.Context, model *models.Catalog, span opentracing.Span) (*models.Catalog, error) {
@@ -45,19 +117,34 @@ func (m *CatalogsRepo) CreateCatalog(ctx context.Context, model *models.Catalog,
 	defer repoSpan.Finish()
 
 	model.ID = primitive.NewObjectID()
-	_, err := m.collection.InsertOne(ctx, model)
-	if err != nil {
-		trace.OnError(m.logger, repoSpan, err)
-		return nil, err
+	model.Version = 1
+	if model.Active {
+		model.SetStatus(models.StatusActive)
+	} else {
+		model.SetStatus(models.StatusInactive)
 	}
+	model.CreatedAt = time.Now().UTC()
+	model.UpdatedAt = model.CreatedAt
 
-	op := &models.Operation{
-		Type:    models.OperationTypeCatalogs,
-		Method:  models.OperationMethodUpsert,
-		Catalog: model,
-	}
+	err := m.withOutbox(ctx, func(sc mongo.SessionContext) (*models.Operation, error) {
+		if err := m.validateParent(sc, model); err != nil {
+			return nil, err
+		}
+		if err := m.validateValue(sc, model); err != nil {
+			return nil, err
+		}
+
+		if _, err := m.collection().InsertOne(sc, model); err != nil {
+			return nil, err
+		}
 
-	if err = m.eventQueue.Publish(op); err != nil {
+		return &models.Operation{
+			Type:    models.OperationTypeCatalogs,
+			Method:  models.OperationMethodUpsert,
+			Catalog: model,
+		}, nil
+	})
+	if err != nil {
 		trace.OnError(m.logger, repoSpan, err)
 		return nil, err
 	}
@@ -71,7 +158,7 @@ func (m *CatalogsRepo) FindCatalogByID(ctx context.Context, id primitive.ObjectI
 	defer repoSpan.Finish()
 
 	var newDocument *models.Catalog
-	err := m.collection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&newDocument)
+	err := m.collection().FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&newDocument)
 	if err != nil {
 		trace.OnError(m.logger, repoSpan, err)
 		return nil, err
@@ -80,19 +167,28 @@ func (m *CatalogsRepo) FindCatalogByID(ctx context.Context, id primitive.ObjectI
 	return newDocument, nil
 }
 
+// FindCatalogsByCategory returns catalogs of the category, every catalog when the category is empty.
 func (m *CatalogsRepo) FindCatalogsByCategory(ctx context.Context, category string, span opentracing.Span) ([]*models.Catalog, error) {
 	tracer := opentracing.GlobalTracer()
 	repoSpan := tracer.StartSpan("Repo:FindCatalogsByCategory", opentracing.ChildOf(span.Context()))
 	defer repoSpan.Finish()
 
-	documents, err := m.collection.Find(ctx, bson.D{})
+	filter := bson.D{}
+	if category != "" {
+		filter = bson.D{{Key: "category", Value: category}}
+	}
+
+	documents, err := m.collection().Find(ctx, filter)
 	if err != nil {
 		trace.OnError(m.logger, repoSpan, err)
 		return nil, err
 	}
 
 	var newDocuments []*models.Catalog
-	err = documents.All(ctx, &newDocuments)
+	if err = documents.All(ctx, &newDocuments); err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, err
+	}
 
 	return newDocuments, nil
 }
@@ -104,9 +200,9 @@ func (m *CatalogsRepo) FindCatalogsCategories(ctx context.Context, span opentrac
 
 	var categories []string
 
-	groupStage := bson.D{{"$group", bson.D{{"_id", "$category"}}}}
+	groupStage := bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$category"}}}}
 
-	docs, err := m.collection.Aggregate(ctx, mongo.Pipeline{groupStage})
+	docs, err := m.collection().Aggregate(ctx, mongo.Pipeline{groupStage})
 	if err != nil {
 		trace.OnError(m.logger, repoSpan, err)
 		return categories, err
@@ -136,67 +232,308 @@ func (m *CatalogsRepo) UpdateCatalog(ctx context.Context, id string, model *mode
 
 	updatedId, _ := primitive.ObjectIDFromHex(id)
 	model.ID = updatedId
-	res, err := m.collection.ReplaceOne(ctx, bson.M{"_id": updatedId}, model)
+	expected := model.Version
+
+	err := m.withOutbox(ctx, func(sc mongo.SessionContext) (*models.Operation, error) {
+		var current models.Catalog
+		if err := m.collection().FindOne(sc, bson.M{"_id": updatedId}).Decode(&current); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, fmt.Errorf("not found record replace one: %w", ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to find one: %w", err)
+		}
+
+		if expected != 0 && expected != current.Version {
+			return nil, fmt.Errorf("expected version %d, current %d: %w", expected, current.Version, ErrVersionConflict)
+		}
+
+		// The state is changed only by the lifecycle methods
+		status := current.EffectiveStatus()
+		if status == models.StatusArchived {
+			return nil, fmt.Errorf("archived catalog can't be updated: %w", ErrInvalidTransition)
+		}
+		model.SetStatus(status)
+
+		if model.Translations == nil {
+			model.Translations = current.Translations
+		}
+
+		if err := m.validateParent(sc, model); err != nil {
+			return nil, err
+		}
+		if err := m.validateValue(sc, model); err != nil {
+			return nil, err
+		}
+
+		// Replace only the read version, a concurrent update makes the filter miss.
+		model.Version = current.Version + 1
+		model.CreatedAt = current.CreatedAt
+		model.UpdatedAt = time.Now().UTC()
+		res, err := m.collection().ReplaceOne(sc, versionFilter(updatedId, current.Version), model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace one: %w", err)
+		}
+
+		if res.MatchedCount == 0 {
+			return nil, fmt.Errorf("replace one: %w", ErrVersionConflict)
+		}
+
+		return &models.Operation{
+			Type:    models.OperationTypeCatalogs,
+			Method:  models.OperationMethodUpsert,
+			Catalog: model,
+		}, nil
+	})
 	if err != nil {
 		trace.OnError(m.logger, repoSpan, err)
-		return nil, fmt.Errorf("failed to replace one: %w", err)
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// DeleteCatalog deactivates the catalog, it stays in Mongo and memory storage until purged.
+func (m *CatalogsRepo) DeleteCatalog(ctx context.Context, id string, span opentracing.Span) error {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:DeleteCatalogs", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	_, err := m.SetCatalogStatus(ctx, id, models.StatusInactive, repoSpan)
+
+	return err
+}
+
+// SetCatalogStatus moves the catalog to the lifecycle state and replicates the whole catalog.
+func (m *CatalogsRepo) SetCatalogStatus(ctx context.Context, id string, status models.CatalogStatus, span opentracing.Span) (*models.Catalog, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:SetCatalogStatus", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
 	}
 
-	if res.MatchedCount == 0 {
+	var model models.Catalog
+	err = m.withOutbox(ctx, func(sc mongo.SessionContext) (*models.Operation, error) {
+		if err := m.collection().FindOne(sc, bson.M{"_id": objectID}).Decode(&model); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to find one: %w", err)
+		}
+
+		current := model.EffectiveStatus()
+		if !models.CanTransition(current, status) {
+			return nil, fmt.Errorf("%s to %s: %w", current, status, ErrInvalidTransition)
+		}
+
+		version := model.Version
+		model.SetStatus(status)
+		model.Version = version + 1
+		model.UpdatedAt = time.Now().UTC()
+
+		res, err := m.collection().ReplaceOne(sc, versionFilter(objectID, version), &model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace one: %w", err)
+		}
+
+		if res.MatchedCount == 0 {
+			return nil, fmt.Errorf("replace one: %w", ErrVersionConflict)
+		}
+
+		return &models.Operation{
+			Type:    models.OperationTypeCatalogs,
+			Method:  models.OperationMethodUpsert,
+			Catalog: &model,
+		}, nil
+	})
+	if err != nil {
 		trace.OnError(m.logger, repoSpan, err)
-		return nil, fmt.Errorf("not found record replace one: %w", err)
+		return nil, err
 	}
 
-	op := &models.Operation{
-		Type:    models.OperationTypeCatalogs,
-		Method:  models.OperationMethodUpsert,
-		Catalog: model,
+	return &model, nil
+}
+
+// SetCatalogTranslations changes translations of the catalog to the given locales, replace drops
+// translations to other locales. Archived catalogs can't be changed.
+func (m *CatalogsRepo) SetCatalogTranslations(ctx context.Context, id string, translations map[string]models.Translation, replace bool, span opentracing.Span) (*models.Catalog, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:SetCatalogTranslations", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
 	}
-	if err = m.eventQueue.Publish(op); err != nil {
+
+	var model models.Catalog
+	err = m.withOutbox(ctx, func(sc mongo.SessionContext) (*models.Operation, error) {
+		if err := m.collection().FindOne(sc, bson.M{"_id": objectID}).Decode(&model); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to find one: %w", err)
+		}
+
+		if model.EffectiveStatus() == models.StatusArchived {
+			return nil, fmt.Errorf("archived catalog can't be updated: %w", ErrInvalidTransition)
+		}
+
+		if replace || model.Translations == nil {
+			model.Translations = make(map[string]models.Translation, len(translations))
+		}
+		for locale, translation := range translations {
+			model.Translations[locale] = translation
+		}
+
+		version := model.Version
+		model.Version = version + 1
+		model.UpdatedAt = time.Now().UTC()
+
+		res, err := m.collection().ReplaceOne(sc, versionFilter(objectID, version), &model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace one: %w", err)
+		}
+
+		if res.MatchedCount == 0 {
+			return nil, fmt.Errorf("replace one: %w", ErrVersionConflict)
+		}
+
+		return &models.Operation{
+			Type:    models.OperationTypeCatalogs,
+			Method:  models.OperationMethodUpsert,
+			Catalog: &model,
+		}, nil
+	})
+	if err != nil {
 		trace.OnError(m.logger, repoSpan, err)
 		return nil, err
 	}
 
-	return model, nil
+	return &model, nil
 }
 
-func (m *CatalogsRepo) DeleteCatalog(ctx context.Context, id string, span opentracing.Span) bool {
+// PurgeCatalog hard-deletes the inactive or archived catalog. Active catalogs must be deactivated first.
+func (m *CatalogsRepo) PurgeCatalog(ctx context.Context, id string, span opentracing.Span) error {
 	tracer := opentracing.GlobalTracer()
-	repoSpan := tracer.StartSpan("Repo:DeleteCatalogs", opentracing.ChildOf(span.Context()))
+	repoSpan := tracer.StartSpan("Repo:PurgeCatalog", opentracing.ChildOf(span.Context()))
 	defer repoSpan.Finish()
 
-	var newDocument *models.Catalog
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	err = m.withOutbox(ctx, func(sc mongo.SessionContext) (*models.Operation, error) {
+		var current models.Catalog
+		if err := m.collection().FindOne(sc, bson.M{"_id": objectID}).Decode(&current); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to find one: %w", err)
+		}
+
+		if current.EffectiveStatus() == models.StatusActive {
+			return nil, fmt.Errorf("active catalog can't be purged: %w", ErrInvalidTransition)
+		}
+
+		children, err := m.collection().CountDocuments(sc, bson.M{"parent_id": objectID}, options.Count().SetLimit(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to count documents: %w", err)
+		}
+		if children > 0 {
+			return nil, ErrHasChildren
+		}
 
-	deletedId, _ := primitive.ObjectIDFromHex(id)
+		res, err := m.collection().DeleteOne(sc, versionFilter(objectID, current.Version))
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete one: %w", err)
+		}
 
-	update := bson.M{
-		"$set": bson.M{"active": false},
+		if res.DeletedCount == 0 {
+			return nil, fmt.Errorf("delete one: %w", ErrVersionConflict)
+		}
+
+		// The next version keeps the delete from being skipped as stale by replication
+		return &models.Operation{
+			Type:   models.OperationTypeCatalogs,
+			Method: models.OperationMethodDelete,
+			Catalog: &models.Catalog{
+				ID:      current.ID,
+				Version: current.Version + 1,
+			},
+		}, nil
+	})
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return err
 	}
 
-	result := m.collection.FindOneAndUpdate(ctx, bson.D{{"_id", deletedId}}, update)
-	if result.Err() != nil {
-		trace.OnError(m.logger, repoSpan, result.Err())
-		return false
+	return nil
+}
+
+// validateParent checks that the parent catalog exists, belongs to the category of the catalog or to its
+// parent category, and is not a descendant of the catalog.
+func (m *CatalogsRepo) validateParent(sc mongo.SessionContext, catalog *models.Catalog) error {
+	if catalog.ParentID == nil {
+		return nil
 	}
 
-	err := result.Decode(&newDocument)
+	parent, err := m.findInTransaction(sc, *catalog.ParentID)
 	if err != nil {
-		trace.OnError(m.logger, repoSpan, err)
-		return false
+		return err
+	}
+	if parent == nil {
+		return fmt.Errorf("catalog %s: %w", catalog.ParentID.Hex(), ErrOrphan)
 	}
 
-	op := &models.Operation{
-		Type:   models.OperationTypeCatalogs,
-		Method: models.OperationMethodDelete,
-		Catalog: &models.Catalog{
-			ID: newDocument.ID,
-		},
+	if parent.Category != catalog.Category {
+		parentCategory, err := parentCategory(sc, catalog.Category)
+		if err != nil {
+			return err
+		}
+		if parent.Category != parentCategory {
+			return fmt.Errorf("category %q: %w", parent.Category, ErrParentCategory)
+		}
 	}
 
-	if err := m.eventQueue.Publish(op); err != nil {
-		trace.OnError(m.logger, repoSpan, err)
-		return false
+	for depth := 0; parent != nil; depth++ {
+		if parent.ID == catalog.ID || depth == maxTreeDepth {
+			return fmt.Errorf("catalog %s: %w", catalog.ParentID.Hex(), ErrCycle)
+		}
+		if parent.ParentID == nil {
+			break
+		}
+
+		if parent, err = m.findInTransaction(sc, *parent.ParentID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findInTransaction returns the catalog with the ID or nil when it does not exist.
+func (m *CatalogsRepo) findInTransaction(sc mongo.SessionContext, id primitive.ObjectID) (*models.Catalog, error) {
+	var catalog models.Catalog
+	if err := m.collection().FindOne(sc, bson.M{"_id": id}).Decode(&catalog); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find one: %w", err)
+	}
+
+	return &catalog, nil
+}
+
+// versionFilter matches the catalog with the given version. Documents written before versioning
+// have no version field and match version zero.
+func versionFilter(id primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "version": bson.M{"$in": bson.A{0, nil}}}
 	}
 
-	return true
+	return bson.M{"_id": id, "version": version}
 }
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// CatalogStamp is the version and the update time of a catalog. Every write of the service bumps both,
+// so catalogs with equal stamps are considered equal.
+type CatalogStamp struct {
+	Version int64
+	// UpdatedAt is the update time in milliseconds, Mongo keeps no finer precision.
+	UpdatedAt int64
+}
+
+// CategoryStamps maps IDs of catalogs of one category to their stamps.
+type CategoryStamps map[string]CatalogStamp
+
+// NewCategoryStamps returns stamps of catalogs of one category the way CatalogStamps reads them from Mongo.
+func NewCategoryStamps(catalogs []*models.Catalog) CategoryStamps {
+	stamps := make(CategoryStamps, len(catalogs))
+	for _, catalog := range catalogs {
+		stamps[catalog.ID.Hex()] = CatalogStamp{Version: catalog.Version, UpdatedAt: millis(catalog.UpdatedAt)}
+	}
+
+	return stamps
+}
+
+// Equal reports whether both have the same catalogs with the same stamps.
+func (s CategoryStamps) Equal(other CategoryStamps) bool {
+	if len(s) != len(other) {
+		return false
+	}
+
+	for id, stamp := range s {
+		if otherStamp, ok := other[id]; !ok || otherStamp != stamp {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CatalogStamps returns stamps of every catalog grouped by category. Only the stamp fields are read.
+func (m *CatalogsRepo) CatalogStamps(ctx context.Context, span opentracing.Span) (map[string]CategoryStamps, error) {
+	tracer := opentracing.GlobalTracer()
+	repoSpan := tracer.StartSpan("Repo:CatalogStamps", opentracing.ChildOf(span.Context()))
+	defer repoSpan.Finish()
+
+	projection := bson.M{"category": 1, "version": 1, "updated_at": 1}
+	cursor, err := m.collection().Find(ctx, bson.D{}, options.Find().SetProjection(projection))
+	if err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, fmt.Errorf("failed to find stamps: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	stamps := make(map[string]CategoryStamps)
+	for cursor.Next(ctx) {
+		var row struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			Category  string             `bson:"category"`
+			Version   int64              `bson:"version"`
+			UpdatedAt time.Time          `bson:"updated_at"`
+		}
+		if err = cursor.Decode(&row); err != nil {
+			trace.OnError(m.logger, repoSpan, err)
+			return nil, fmt.Errorf("failed to decode stamp: %w", err)
+		}
+
+		category, ok := stamps[row.Category]
+		if !ok {
+			category = make(CategoryStamps)
+			stamps[row.Category] = category
+		}
+		category[row.ID.Hex()] = CatalogStamp{Version: row.Version, UpdatedAt: millis(row.UpdatedAt)}
+	}
+
+	if err = cursor.Err(); err != nil {
+		trace.OnError(m.logger, repoSpan, err)
+		return nil, fmt.Errorf("failed to read stamps: %w", err)
+	}
+
+	return stamps, nil
+}
+
+// millis returns the time in milliseconds since the Unix epoch, zero for the zero time.
+func millis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -3,23 +3,62 @@ package usecases
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/afiskon/promtail-client/promtail"
 	"github.com/mitchellh/mapstructure"
 	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/locale"
 	"github.com/rusrafkasimov/catalogs/internal/trace"
 	"github.com/rusrafkasimov/catalogs/pkg/dto"
 	"github.com/rusrafkasimov/catalogs/pkg/models"
 	"github.com/rusrafkasimov/catalogs/pkg/repository/memstore"
 	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/collate"
+	"sort"
+)
+
+const (
+	defaultCatalogsPageSize = 50
+	maxCatalogsPageSize     = 1000
+)
+
+var (
+	// ErrVersionConflict is returned by update when the catalog was changed concurrently.
+	ErrVersionConflict = repository.ErrVersionConflict
+	// ErrCatalogNotFound is returned when there is no matching catalog.
+	ErrCatalogNotFound = repository.ErrNotFound
+	// ErrInvalidTransition is returned when the catalog can't change its lifecycle state or be updated in it.
+	ErrInvalidTransition = repository.ErrInvalidTransition
+	// ErrOrphan, ErrCycle and ErrParentCategory are returned for an invalid parent of a catalog or category.
+	ErrOrphan         = repository.ErrOrphan
+	ErrCycle          = repository.ErrCycle
+	ErrParentCategory = repository.ErrParentCategory
+	// ErrHasChildren is returned when a catalog with children is purged.
+	ErrHasChildren = repository.ErrHasChildren
+	// ErrSchemaViolation is returned when the value is not allowed by the schema of the category.
+	ErrSchemaViolation = repository.ErrSchemaViolation
+	// ErrInvalidCatalogsQuery is returned for unknown sort field or order and malformed cursor.
+	ErrInvalidCatalogsQuery = errors.New("invalid catalogs query")
 )
 
 type CatalogsUseCase interface {
 	CreateCatalog(ctx context.Context, request *dto.CatalogRequest, span opentracing.Span) (*dto.CreateCatalogResponse, error)
 	GetCatalogs(ctx context.Context, request *dto.CatalogsRequest, span opentracing.Span) (*dto.GetCatalogsResponse, error)
-	GetCatalogCategories(ctx context.Context, span opentracing.Span) (*dto.GetCategoriesResponse, error)
-	GetCatalogByID(ctx context.Context, id string, span opentracing.Span) (*dto.GetCatalogResponse, error)
+	SearchCatalogs(ctx context.Context, request *dto.SearchCatalogsRequest, span opentracing.Span) (*dto.SearchCatalogsResponse, error)
+	SuggestCatalogs(ctx context.Context, request *dto.SuggestCatalogsRequest, span opentracing.Span) (*dto.SuggestCatalogsResponse, error)
+	LookupCatalog(ctx context.Context, request *dto.LookupCatalogRequest, span opentracing.Span) (*dto.GetCatalogResponse, error)
+	GetRecentCatalogs(ctx context.Context, request *dto.RecentCatalogsRequest, span opentracing.Span) (*dto.GetCatalogsResponse, error)
+	GetCatalogByID(ctx context.Context, id string, request *dto.GetCatalogRequest, span opentracing.Span) (*dto.GetCatalogResponse, error)
 	UpdateCatalogByID(ctx context.Context, request *dto.CatalogRequest, span opentracing.Span) (*dto.UpdateCatalogResponse, error)
 	DeleteCatalogByID(ctx context.Context, id string, span opentracing.Span) (*dto.DeleteCatalogResponse, error)
+	SetCatalogStatus(ctx context.Context, id string, status models.CatalogStatus, span opentracing.Span) (*dto.UpdateCatalogResponse, error)
+	PurgeCatalogByID(ctx context.Context, id string, span opentracing.Span) (*dto.PurgeCatalogResponse, error)
+	GetSubtree(ctx context.Context, id string, request *dto.SubtreeRequest, span opentracing.Span) (*dto.GetSubtreeResponse, error)
+	GetAncestors(ctx context.Context, id string, request *dto.AncestorsRequest, span opentracing.Span) (*dto.GetAncestorsResponse, error)
+	GetBreadcrumbs(ctx context.Context, id string, request *dto.AncestorsRequest, span opentracing.Span) (*dto.GetBreadcrumbsResponse, error)
+	ExportTranslations(ctx context.Context, request *dto.ExportTranslationsRequest, span opentracing.Span) (*dto.ExportTranslationsResponse, error)
+	ImportTranslations(ctx context.Context, request *dto.ImportTranslationsRequest, span opentracing.Span) (*dto.ImportTranslationsResponse, error)
 }
 
 type CatalogsUC struct {
@@ -49,6 +88,12 @@ func (c *CatalogsUC) CreateCatalog(ctx context.Context, request *dto.CatalogRequ
 		return nil, err
 	}
 
+	if catalog.ParentID, err = parentID(request.ParentID); err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+	catalog.Translations = translationsModel(request.Translations)
+
 	model, err := c.rep.CreateCatalog(ctx, catalog, useCaseSpan)
 	if err != nil {
 		trace.OnError(c.logger, useCaseSpan, err)
@@ -60,6 +105,8 @@ func (c *CatalogsUC) CreateCatalog(ctx context.Context, request *dto.CatalogRequ
 		trace.OnError(c.logger, useCaseSpan, err)
 		return &result, err
 	}
+	result.Payload.Translations = translationsResponse(model.Translations)
+
 	return &result, nil
 }
 
@@ -69,55 +116,236 @@ func (c *CatalogsUC) GetCatalogs(ctx context.Context, request *dto.CatalogsReque
 	defer useCaseSpan.Finish()
 	var result dto.GetCatalogsResponse
 
-	documents, ok := c.store.GetCatalogByCategoryAndQuery(request.Category, request.Query, request.Sorted)
+	query, err := catalogsQuery(request)
+	if err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+	query.Locale = locale.FromContext(ctx)
+
+	page, ok, err := c.store.FindCatalogs(query)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidCatalogsQuery, err)
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
 	if !ok {
 		trace.OnError(c.logger, useCaseSpan, errors.New("memstore is empty"))
 		return &result, errors.New("memstore is empty")
 	}
 
-	for _, entry := range documents {
-		newModel := dto.CatalogResponse{
+	for _, entry := range page.Items {
+		result.Payload = append(result.Payload, convertCatalog(entry, locale.FromContext(ctx)))
+	}
+
+	result.Meta.NumOfResults = int64(page.Total)
+	result.Meta.PageSize = query.Limit
+	result.Meta.NumOfPages = int64((page.Total + query.Limit - 1) / query.Limit)
+	result.Meta.NextCursor = page.NextCursor
+	if query.Cursor == "" {
+		result.Meta.CurrentPage = int32(query.Offset/query.Limit + 1)
+	}
+
+	return &result, nil
+}
+
+// catalogsQuery converts the request to the memory storage query, applying defaults.
+func catalogsQuery(request *dto.CatalogsRequest) (memstore.Query, error) {
+	query := memstore.Query{
+		Category: request.Category,
+		Search:   request.Query,
+		Active:   request.Active,
+		SortBy:   memstore.SortField(request.SortBy),
+		Cursor:   request.Cursor,
+		Limit:    request.PageSize,
+	}
+
+	if query.Active == nil && !request.IncludeInactive {
+		active := true
+		query.Active = &active
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = memstore.SortByName
+	}
+	if !memstore.ValidSortField(query.SortBy) {
+		return query, fmt.Errorf("%w: unknown sort field %q", ErrInvalidCatalogsQuery, request.SortBy)
+	}
+
+	switch request.Order {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, fmt.Errorf("%w: unknown order %q", ErrInvalidCatalogsQuery, request.Order)
+	}
+
+	if request.Page < 0 || request.PageSize < 0 {
+		return query, fmt.Errorf("%w: page and page size must not be negative", ErrInvalidCatalogsQuery)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultCatalogsPageSize
+	}
+	if query.Limit > maxCatalogsPageSize {
+		query.Limit = maxCatalogsPageSize
+	}
+
+	if request.Page > 1 {
+		query.Offset = (request.Page - 1) * query.Limit
+	}
+
+	return query, nil
+}
+
+func (c *CatalogsUC) SearchCatalogs(ctx context.Context, request *dto.SearchCatalogsRequest, span opentracing.Span) (*dto.SearchCatalogsResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:SearchCatalogs", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.SearchCatalogsResponse
+
+	query := memstore.SearchQuery{
+		Text:     request.Query,
+		Category: request.Category,
+		Active:   request.Active,
+		Fuzzy:    request.Fuzzy,
+		Limit:    request.Limit,
+		Locale:   locale.FromContext(ctx),
+	}
+	if query.Active == nil && !request.IncludeInactive {
+		active := true
+		query.Active = &active
+	}
+
+	found := c.store.SearchCatalogs(query)
+
+	result.Payload = make([]dto.SearchCatalogResponse, 0, len(found))
+	for _, entry := range found {
+		newModel := dto.SearchCatalogResponse{
+			CatalogResponse: convertCatalog(entry.Catalog, locale.FromContext(ctx)),
+			Score:           entry.Score,
+		}
+		result.Payload = append(result.Payload, newModel)
+	}
+
+	result.Meta.NumOfResults = int64(len(found))
+	result.Meta.PageSize = request.Limit
+
+	return &result, nil
+}
+
+func (c *CatalogsUC) SuggestCatalogs(ctx context.Context, request *dto.SuggestCatalogsRequest, span opentracing.Span) (*dto.SuggestCatalogsResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:SuggestCatalogs", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.SuggestCatalogsResponse
+
+	found := c.store.SuggestCatalogs(request.Prefix, request.Category, locale.FromContext(ctx), request.Limit)
+
+	result.Payload = make([]dto.SuggestionResponse, 0, len(found))
+	for _, entry := range found {
+		result.Payload = append(result.Payload, dto.SuggestionResponse{
 			ID:       entry.ID,
-			Name:     entry.Name,
 			Category: entry.Category,
+			Name:     entry.Localized(locale.FromContext(ctx)).Name,
 			Value:    entry.Value,
-		}
-		result.Payload = append(result.Payload, newModel)
+		})
 	}
 
+	result.Meta.NumOfResults = int64(len(found))
+	result.Meta.PageSize = request.Limit
+
 	return &result, nil
 }
 
-func (c *CatalogsUC) GetCatalogCategories(ctx context.Context, span opentracing.Span) (*dto.GetCategoriesResponse, error) {
+func (c *CatalogsUC) LookupCatalog(ctx context.Context, request *dto.LookupCatalogRequest, span opentracing.Span) (*dto.GetCatalogResponse, error) {
 	tracer := opentracing.GlobalTracer()
-	useCaseSpan := tracer.StartSpan("UCase:GetCatalogCategories", opentracing.ChildOf(span.Context()))
+	useCaseSpan := tracer.StartSpan("UCase:LookupCatalog", opentracing.ChildOf(span.Context()))
 	defer useCaseSpan.Finish()
-	var result dto.GetCategoriesResponse
+	var result dto.GetCatalogResponse
+
+	catalog, ok := c.store.GetCatalogByValue(request.Category, request.Value)
+	if !ok || (!catalog.Active && !request.IncludeInactive) {
+		return nil, ErrCatalogNotFound
+	}
+
+	result.Payload.CatalogResponse = convertCatalog(catalog, locale.FromContext(ctx))
+
+	return &result, nil
+}
+
+func (c *CatalogsUC) GetRecentCatalogs(ctx context.Context, request *dto.RecentCatalogsRequest, span opentracing.Span) (*dto.GetCatalogsResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetRecentCatalogs", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetCatalogsResponse
+
+	documents := c.store.GetRecentlyChanged(request.Since, request.Limit, !request.IncludeInactive)
 
-	categories := c.store.GetCategories()
+	result.Payload = make([]dto.CatalogResponse, 0, len(documents))
+	for _, entry := range documents {
+		result.Payload = append(result.Payload, convertCatalog(entry, locale.FromContext(ctx)))
+	}
 
-	result.Payload = categories
+	result.Meta.NumOfResults = int64(len(documents))
+	result.Meta.PageSize = request.Limit
 
 	return &result, nil
 }
 
-func (c *CatalogsUC) GetCatalogByID(ctx context.Context, id string, span opentracing.Span) (*dto.GetCatalogResponse, error) {
+// convertCatalog converts the stored catalog to its response with the name and the description in the locale.
+func convertCatalog(catalog *models.Catalog, loc string) dto.CatalogResponse {
+	localized := catalog.Localized(loc)
+
+	return dto.CatalogResponse{
+		ID:       catalog.ID,
+		Active:   catalog.Active,
+		Category: catalog.Category,
+		Name:     localized.Name,
+		Desc:     localized.Desc,
+		Value:    catalog.Value,
+		Version:  catalog.Version,
+		Status:   string(catalog.EffectiveStatus()),
+		ParentID: catalog.ParentID,
+	}
+}
+
+// storeKey returns the memory storage key of the catalog ID given in hex or in the key form.
+func storeKey(id string) string {
+	if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+		return objectID.String()
+	}
+
+	return id
+}
+
+// parentID parses the hex ID of the parent catalog, empty ID means no parent.
+func parentID(id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("catalog %q: %w", id, ErrOrphan)
+	}
+
+	return &objectID, nil
+}
+
+func (c *CatalogsUC) GetCatalogByID(ctx context.Context, id string, request *dto.GetCatalogRequest, span opentracing.Span) (*dto.GetCatalogResponse, error) {
 	tracer := opentracing.GlobalTracer()
 	useCaseSpan := tracer.StartSpan("UCase:GetCatalogByID", opentracing.ChildOf(span.Context()))
 	defer useCaseSpan.Finish()
 	var result dto.GetCatalogResponse
 
-	media, ok := c.store.GetCatalog(id)
-	if !ok {
-		trace.OnError(c.logger, useCaseSpan, errors.New("memstore error"))
-		return nil, errors.New("not found")
+	media, ok := c.store.GetCatalog(storeKey(id))
+	if !ok || (!media.Active && !request.IncludeInactive) {
+		trace.OnError(c.logger, useCaseSpan, ErrCatalogNotFound)
+		return nil, ErrCatalogNotFound
 	}
 
-	result.Payload.ID = media.ID
-	result.Payload.Active = media.Active
-	result.Payload.Name = media.Name
-	result.Payload.Value = media.Value
-	result.Payload.Category = media.Category
+	result.Payload.CatalogResponse = convertCatalog(media, locale.FromContext(ctx))
 
 	return &result, nil
 }
@@ -135,6 +363,12 @@ func (c *CatalogsUC) UpdateCatalogByID(ctx context.Context, request *dto.Catalog
 		return nil, err
 	}
 
+	if catalog.ParentID, err = parentID(request.ParentID); err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+	catalog.Translations = translationsModel(request.Translations)
+
 	model, err := c.rep.UpdateCatalog(ctx, request.ID, catalog, useCaseSpan)
 	if err != nil {
 		trace.OnError(c.logger, useCaseSpan, err)
@@ -146,6 +380,7 @@ func (c *CatalogsUC) UpdateCatalogByID(ctx context.Context, request *dto.Catalog
 		trace.OnError(c.logger, useCaseSpan, err)
 		return &result, err
 	}
+	result.Payload.Translations = translationsResponse(model.Translations)
 
 	return &result, nil
 }
@@ -156,13 +391,222 @@ func (c *CatalogsUC) DeleteCatalogByID(ctx context.Context, id string, span open
 	defer useCaseSpan.Finish()
 	var result dto.DeleteCatalogResponse
 
-	ok := c.rep.DeleteCatalog(ctx, id, useCaseSpan)
-	if !ok {
-		trace.OnError(c.logger, useCaseSpan, errors.New("not found"))
-		return nil, errors.New("not found")
+	if err := c.rep.DeleteCatalog(ctx, id, useCaseSpan); err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
 	}
 
 	result.Payload.Active = false
 
 	return &result, nil
 }
+
+func (c *CatalogsUC) SetCatalogStatus(ctx context.Context, id string, status models.CatalogStatus, span opentracing.Span) (*dto.UpdateCatalogResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:SetCatalogStatus", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.UpdateCatalogResponse
+
+	model, err := c.rep.SetCatalogStatus(ctx, id, status, useCaseSpan)
+	if err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload.CatalogResponse = convertCatalog(model, locale.FromContext(ctx))
+
+	return &result, nil
+}
+
+func (c *CatalogsUC) PurgeCatalogByID(ctx context.Context, id string, span opentracing.Span) (*dto.PurgeCatalogResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:PurgeCatalogByID", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.PurgeCatalogResponse
+
+	if err := c.rep.PurgeCatalog(ctx, id, useCaseSpan); err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload.ID = id
+	result.Payload.Purged = true
+
+	return &result, nil
+}
+
+func (c *CatalogsUC) GetSubtree(ctx context.Context, id string, request *dto.SubtreeRequest, span opentracing.Span) (*dto.GetSubtreeResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetSubtree", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetSubtreeResponse
+
+	root, ok := c.store.GetSubtree(storeKey(id), request.Depth, !request.IncludeInactive)
+	if !ok || (!root.Catalog.Active && !request.IncludeInactive) {
+		return nil, ErrCatalogNotFound
+	}
+
+	loc := locale.FromContext(ctx)
+	result.Payload = convertTreeNode(root, loc, locale.Collator(loc))
+
+	return &result, nil
+}
+
+func (c *CatalogsUC) GetAncestors(ctx context.Context, id string, request *dto.AncestorsRequest, span opentracing.Span) (*dto.GetAncestorsResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetAncestors", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetAncestorsResponse
+
+	ancestors, ok := c.store.GetAncestors(storeKey(id), !request.IncludeInactive)
+	if !ok {
+		return nil, ErrCatalogNotFound
+	}
+
+	result.Payload = make([]dto.CatalogResponse, 0, len(ancestors))
+	for _, entry := range ancestors {
+		result.Payload = append(result.Payload, convertCatalog(entry, locale.FromContext(ctx)))
+	}
+
+	result.Meta.NumOfResults = int64(len(ancestors))
+
+	return &result, nil
+}
+
+func (c *CatalogsUC) GetBreadcrumbs(ctx context.Context, id string, request *dto.AncestorsRequest, span opentracing.Span) (*dto.GetBreadcrumbsResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetBreadcrumbs", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetBreadcrumbsResponse
+
+	catalog, ok := c.store.GetCatalog(storeKey(id))
+	if !ok {
+		return nil, ErrCatalogNotFound
+	}
+	ancestors, ok := c.store.GetAncestors(storeKey(id), !request.IncludeInactive)
+	if !ok {
+		return nil, ErrCatalogNotFound
+	}
+
+	result.Payload = make([]dto.BreadcrumbResponse, 0, len(ancestors)+1)
+	for _, entry := range append(ancestors, catalog) {
+		result.Payload = append(result.Payload, dto.BreadcrumbResponse{
+			ID:       entry.ID,
+			Category: entry.Category,
+			Name:     entry.Localized(locale.FromContext(ctx)).Name,
+		})
+	}
+
+	result.Meta.NumOfResults = int64(len(result.Payload))
+
+	return &result, nil
+}
+
+// ExportTranslations returns names, descriptions and translations of catalogs sorted by category and ID.
+func (c *CatalogsUC) ExportTranslations(ctx context.Context, request *dto.ExportTranslationsRequest, span opentracing.Span) (*dto.ExportTranslationsResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:ExportTranslations", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.ExportTranslationsResponse
+
+	catalogs := c.store.GetAllCatalogs()
+	sort.Slice(catalogs, func(i, j int) bool {
+		if catalogs[i].Category != catalogs[j].Category {
+			return catalogs[i].Category < catalogs[j].Category
+		}
+		return catalogs[i].ID.Hex() < catalogs[j].ID.Hex()
+	})
+
+	result.Payload = make([]dto.CatalogTranslationsResponse, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		if request.Category != "" && catalog.Category != request.Category {
+			continue
+		}
+		if !catalog.Active && !request.IncludeInactive {
+			continue
+		}
+
+		translations := translationsResponse(catalog.Translations)
+		if translations == nil {
+			translations = make(map[string]dto.Translation)
+		}
+
+		result.Payload = append(result.Payload, dto.CatalogTranslationsResponse{
+			ID:           catalog.ID,
+			Category:     catalog.Category,
+			Value:        catalog.Value,
+			Name:         catalog.Name,
+			Desc:         catalog.Desc,
+			Translations: translations,
+		})
+	}
+
+	result.Meta.NumOfResults = int64(len(result.Payload))
+
+	return &result, nil
+}
+
+// ImportTranslations changes translations of every item. Items are imported one by one, failed items
+// are reported and do not stop the import.
+func (c *CatalogsUC) ImportTranslations(ctx context.Context, request *dto.ImportTranslationsRequest, span opentracing.Span) (*dto.ImportTranslationsResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:ImportTranslations", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.ImportTranslationsResponse
+
+	result.Payload.Failed = make([]dto.ImportErrorResponse, 0)
+	for _, item := range request.Items {
+		_, err := c.rep.SetCatalogTranslations(ctx, item.ID, translationsModel(item.Translations), request.Replace, useCaseSpan)
+		if err != nil {
+			trace.OnError(c.logger, useCaseSpan, err)
+			result.Payload.Failed = append(result.Payload.Failed, dto.ImportErrorResponse{ID: item.ID, Error: err.Error()})
+			continue
+		}
+
+		result.Payload.Updated++
+	}
+
+	return &result, nil
+}
+
+// translationsModel converts translations of the request, nil stays nil.
+func translationsModel(translations map[string]dto.Translation) map[string]models.Translation {
+	if translations == nil {
+		return nil
+	}
+
+	out := make(map[string]models.Translation, len(translations))
+	for loc, translation := range translations {
+		out[loc] = models.Translation{Name: translation.Name, Desc: translation.Desc}
+	}
+
+	return out
+}
+
+// translationsResponse converts stored translations, empty translations are nil.
+func translationsResponse(translations map[string]models.Translation) map[string]dto.Translation {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	out := make(map[string]dto.Translation, len(translations))
+	for loc, translation := range translations {
+		out[loc] = dto.Translation{Name: translation.Name, Desc: translation.Desc}
+	}
+
+	return out
+}
+
+// convertTreeNode converts the subtree to its response, children are sorted by names in the locale.
+func convertTreeNode(node *memstore.TreeNode, loc string, collator *collate.Collator) dto.TreeNodeResponse {
+	res := dto.TreeNodeResponse{CatalogResponse: convertCatalog(node.Catalog, loc)}
+	for _, child := range node.Children {
+		res.Children = append(res.Children, convertTreeNode(child, loc, collator))
+	}
+
+	sort.SliceStable(res.Children, func(i, j int) bool {
+		return collator.CompareString(res.Children[i].Name, res.Children[j].Name) < 0
+	})
+
+	return res
+}
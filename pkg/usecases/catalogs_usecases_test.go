@@ -0,0 +1,103 @@
+package usecases
+
+import (
+	"context"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/pkg/dto"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+// nopLogger discards log messages.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Shutdown()                     {}
+
+// fakeCatalogsRepo stores written catalogs, other methods of the repository are not implemented.
+type fakeCatalogsRepo struct {
+	repository.CatalogsRepository
+
+	created   *models.Catalog
+	updated   *models.Catalog
+	updatedID string
+}
+
+func (r *fakeCatalogsRepo) CreateCatalog(_ context.Context, model *models.Catalog, _ opentracing.Span) (*models.Catalog, error) {
+	r.created = model
+
+	stored := *model
+	stored.ID = primitive.NewObjectID()
+	stored.Version = 1
+
+	return &stored, nil
+}
+
+func (r *fakeCatalogsRepo) UpdateCatalog(_ context.Context, id string, model *models.Catalog, _ opentracing.Span) (*models.Catalog, error) {
+	r.updatedID = id
+	r.updated = model
+
+	stored := *model
+	stored.ID, _ = primitive.ObjectIDFromHex(id)
+	stored.Version++
+
+	return &stored, nil
+}
+
+func TestCatalogRequestWithIDDecodes(t *testing.T) {
+	repo := &fakeCatalogsRepo{}
+	uc := NewCatalogsUseCases(repo, nil, nopLogger{})
+	span := opentracing.NoopTracer{}.StartSpan("test")
+
+	id := primitive.NewObjectID()
+	parent := primitive.NewObjectID()
+	request := &dto.CatalogRequest{
+		ID:       id.Hex(),
+		Active:   true,
+		Category: "colors",
+		Name:     "Red",
+		Value:    "red",
+		Version:  3,
+		ParentID: parent.Hex(),
+		Translations: map[string]dto.Translation{
+			"en": {Name: "Red"},
+		},
+	}
+
+	created, err := uc.CreateCatalog(context.Background(), request, span)
+	if err != nil {
+		t.Fatalf("CreateCatalog: %v", err)
+	}
+	if !repo.created.ID.IsZero() {
+		t.Fatalf("create took the request id %s", repo.created.ID.Hex())
+	}
+	if repo.created.Name != "Red" || repo.created.Value != "red" || !repo.created.Active {
+		t.Fatalf("created catalog = %+v", repo.created)
+	}
+	if repo.created.ParentID == nil || *repo.created.ParentID != parent {
+		t.Fatalf("created parent = %v, want %s", repo.created.ParentID, parent.Hex())
+	}
+	if created.Payload.ID.IsZero() {
+		t.Fatal("created catalog has no id")
+	}
+
+	request.Name = "Crimson"
+	updated, err := uc.UpdateCatalogByID(context.Background(), request, span)
+	if err != nil {
+		t.Fatalf("UpdateCatalogByID: %v", err)
+	}
+	if repo.updatedID != id.Hex() {
+		t.Fatalf("updated id = %q, want %q", repo.updatedID, id.Hex())
+	}
+	if repo.updated.Name != "Crimson" || repo.updated.Version != 3 {
+		t.Fatalf("updated catalog = %+v", repo.updated)
+	}
+	if updated.Payload.ID != id || updated.Payload.Translations["en"].Name != "Red" {
+		t.Fatalf("update response = %+v", updated.Payload)
+	}
+}
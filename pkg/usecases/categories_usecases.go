@@ -0,0 +1,324 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/locale"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/dto"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	"github.com/rusrafkasimov/catalogs/pkg/repository/memstore"
+	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+	"sort"
+)
+
+// rootCategory is the parent of root categories in the category tree.
+const rootCategory = "\x00root"
+
+var (
+	// ErrCategoryNotFound is returned when the category has neither metadata nor catalogs.
+	ErrCategoryNotFound = repository.ErrCategoryNotFound
+	// ErrCategoryExists is returned when metadata of the category is created twice.
+	ErrCategoryExists = repository.ErrCategoryExists
+	// ErrCategoryInUse is returned when a category with catalogs or child categories is deleted.
+	ErrCategoryInUse = repository.ErrCategoryInUse
+	// ErrInvalidSchema is returned when the value schema can't be compiled.
+	ErrInvalidSchema = repository.ErrInvalidSchema
+	// ErrInvalidCategory is returned when the category has no name.
+	ErrInvalidCategory = errors.New("category name is required")
+)
+
+type CategoriesUseCase interface {
+	GetCategories(ctx context.Context, span opentracing.Span) (*dto.GetCategoriesResponse, error)
+	GetCategory(ctx context.Context, name string, span opentracing.Span) (*dto.GetCategoryResponse, error)
+	CreateCategory(ctx context.Context, request *dto.CategoryRequest, span opentracing.Span) (*dto.CreateCategoryResponse, error)
+	UpdateCategory(ctx context.Context, name string, request *dto.CategoryRequest, span opentracing.Span) (*dto.UpdateCategoryResponse, error)
+	DeleteCategory(ctx context.Context, name string, span opentracing.Span) (*dto.DeleteCategoryResponse, error)
+	GetCategoryTree(ctx context.Context, span opentracing.Span) (*dto.GetCategoryTreeResponse, error)
+	SetCategoryParent(ctx context.Context, name string, request *dto.CategoryParentRequest, span opentracing.Span) (*dto.UpdateCategoryResponse, error)
+}
+
+type CategoriesUC struct {
+	rep    repository.CategoriesRepository
+	store  memstore.MemStore
+	logger promtail.Client
+}
+
+func NewCategoriesUseCases(rep repository.CategoriesRepository, store memstore.MemStore, logger promtail.Client) *CategoriesUC {
+	return &CategoriesUC{
+		rep:    rep,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// GetCategories returns categories with metadata or catalogs ordered by sort order and display name
+// in the locale. Categories without metadata have only the name.
+func (c *CategoriesUC) GetCategories(ctx context.Context, span opentracing.Span) (*dto.GetCategoriesResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetCategories", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetCategoriesResponse
+
+	categories, err := c.rep.FindCategories(ctx, useCaseSpan)
+	if err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	loc := locale.FromContext(ctx)
+
+	managed := make(map[string]bool, len(categories))
+	result.Payload = make([]dto.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		managed[category.Name] = true
+		result.Payload = append(result.Payload, convertCategory(category, loc))
+	}
+	for _, name := range c.store.GetCategories() {
+		if !managed[name] {
+			result.Payload = append(result.Payload, dto.CategoryResponse{Name: name, DisplayName: name})
+		}
+	}
+
+	collator := locale.Collator(loc)
+	sort.SliceStable(result.Payload, func(i, j int) bool {
+		if result.Payload[i].SortOrder != result.Payload[j].SortOrder {
+			return result.Payload[i].SortOrder < result.Payload[j].SortOrder
+		}
+		if order := collator.CompareString(result.Payload[i].DisplayName, result.Payload[j].DisplayName); order != 0 {
+			return order < 0
+		}
+		return result.Payload[i].Name < result.Payload[j].Name
+	})
+
+	result.Meta.NumOfResults = int64(len(result.Payload))
+
+	return &result, nil
+}
+
+// GetCategory returns metadata of the category, or only its name when it has catalogs but no metadata.
+func (c *CategoriesUC) GetCategory(ctx context.Context, name string, span opentracing.Span) (*dto.GetCategoryResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetCategory", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetCategoryResponse
+
+	category, err := c.rep.FindCategory(ctx, name, useCaseSpan)
+	switch {
+	case err == nil:
+		result.Payload = convertCategory(category, locale.FromContext(ctx))
+	case errors.Is(err, ErrCategoryNotFound) && c.hasCatalogs(name):
+		result.Payload.Name = name
+		result.Payload.DisplayName = name
+	default:
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *CategoriesUC) CreateCategory(ctx context.Context, request *dto.CategoryRequest, span opentracing.Span) (*dto.CreateCategoryResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:CreateCategory", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.CreateCategoryResponse
+
+	if request.Name == "" {
+		trace.OnError(c.logger, useCaseSpan, ErrInvalidCategory)
+		return nil, ErrInvalidCategory
+	}
+
+	category, err := c.rep.CreateCategory(ctx, categoryModel(request.Name, request), useCaseSpan)
+	if err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload = convertCategory(category, locale.FromContext(ctx))
+
+	return &result, nil
+}
+
+func (c *CategoriesUC) UpdateCategory(ctx context.Context, name string, request *dto.CategoryRequest, span opentracing.Span) (*dto.UpdateCategoryResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:UpdateCategory", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.UpdateCategoryResponse
+
+	category, err := c.rep.UpdateCategory(ctx, categoryModel(name, request), useCaseSpan)
+	if err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload = convertCategory(category, locale.FromContext(ctx))
+
+	return &result, nil
+}
+
+func (c *CategoriesUC) DeleteCategory(ctx context.Context, name string, span opentracing.Span) (*dto.DeleteCategoryResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:DeleteCategory", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.DeleteCategoryResponse
+
+	if err := c.rep.DeleteCategory(ctx, name, useCaseSpan); err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload.Name = name
+
+	return &result, nil
+}
+
+// GetCategoryTree returns categories with catalogs or metadata as a forest sorted by name, display names
+// are in the locale.
+func (c *CategoriesUC) GetCategoryTree(ctx context.Context, span opentracing.Span) (*dto.GetCategoryTreeResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetCategoryTree", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetCategoryTreeResponse
+
+	categories, err := c.rep.FindCategories(ctx, useCaseSpan)
+	if err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	loc := locale.FromContext(ctx)
+
+	parents := make(map[string]string)
+	displayNames := make(map[string]string)
+	for _, name := range c.store.GetCategories() {
+		parents[name] = ""
+		displayNames[name] = name
+	}
+	for _, category := range categories {
+		parents[category.Name] = category.Parent
+		displayNames[category.Name] = category.Localized(loc).Name
+	}
+
+	children := make(map[string][]string)
+	for name, parent := range parents {
+		if _, ok := parents[parent]; !ok || parent == "" {
+			parent = rootCategory
+		}
+		children[parent] = append(children[parent], name)
+	}
+
+	result.Payload = categoryTree(children, displayNames, rootCategory, make(map[string]bool))
+	result.Meta.NumOfResults = int64(len(parents))
+
+	return &result, nil
+}
+
+func (c *CategoriesUC) SetCategoryParent(ctx context.Context, name string, request *dto.CategoryParentRequest, span opentracing.Span) (*dto.UpdateCategoryResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:SetCategoryParent", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.UpdateCategoryResponse
+
+	category, err := c.rep.SetCategoryParent(ctx, name, request.Parent, useCaseSpan)
+	if err != nil {
+		trace.OnError(c.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload = convertCategory(category, locale.FromContext(ctx))
+
+	return &result, nil
+}
+
+// hasCatalogs reports whether memory storage has catalogs of the category.
+func (c *CategoriesUC) hasCatalogs(name string) bool {
+	for _, category := range c.store.GetCategories() {
+		if category == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// categoryModel converts the request to the stored category with the name.
+func categoryModel(name string, request *dto.CategoryRequest) *models.Category {
+	category := &models.Category{
+		Name:        name,
+		DisplayName: request.DisplayName,
+		Description: request.Description,
+		OwnerTeam:   request.OwnerTeam,
+		SortOrder:   request.SortOrder,
+		Parent:      request.Parent,
+	}
+	category.Translations = translationsModel(request.Translations)
+
+	if schema := request.Schema; schema != nil {
+		category.Schema = &models.ValueSchema{
+			Type:       models.SchemaType(schema.Type),
+			Pattern:    schema.Pattern,
+			Enum:       schema.Enum,
+			JSONSchema: string(schema.JSONSchema),
+		}
+	}
+
+	return category
+}
+
+// convertCategory converts the stored category to its response with the display name and the description
+// in the locale.
+func convertCategory(category *models.Category, loc string) dto.CategoryResponse {
+	localized := category.Localized(loc)
+
+	response := dto.CategoryResponse{
+		Name:         category.Name,
+		DisplayName:  localized.Name,
+		Description:  localized.Desc,
+		Translations: translationsResponse(category.Translations),
+		OwnerTeam:    category.OwnerTeam,
+		SortOrder:    category.SortOrder,
+		Parent:       category.Parent,
+		Managed:      true,
+		CreatedAt:    &category.CreatedAt,
+		UpdatedAt:    &category.UpdatedAt,
+	}
+
+	if schema := category.Schema; schema != nil {
+		response.Schema = &dto.ValueSchema{
+			Type:    string(schema.Type),
+			Pattern: schema.Pattern,
+			Enum:    schema.Enum,
+		}
+		if schema.JSONSchema != "" {
+			response.Schema.JSONSchema = json.RawMessage(schema.JSONSchema)
+		}
+	}
+
+	return response
+}
+
+// categoryTree builds nodes of the children of the parent. visited guards against cycles.
+func categoryTree(children map[string][]string, displayNames map[string]string, parent string, visited map[string]bool) []dto.CategoryTreeNodeResponse {
+	names := children[parent]
+	sort.Strings(names)
+
+	nodes := make([]dto.CategoryTreeNodeResponse, 0, len(names))
+	for _, name := range names {
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+
+		nodes = append(nodes, dto.CategoryTreeNodeResponse{
+			Name:        name,
+			DisplayName: displayNames[name],
+			Children:    categoryTree(children, displayNames, name, visited),
+		})
+	}
+
+	return nodes
+}
@@ -0,0 +1,135 @@
+package usecases
+
+import (
+	"context"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/opentracing/opentracing-go"
+	"github.com/rusrafkasimov/catalogs/internal/trace"
+	"github.com/rusrafkasimov/catalogs/pkg/dto"
+	"github.com/rusrafkasimov/catalogs/pkg/models"
+	repository "github.com/rusrafkasimov/catalogs/pkg/repository/mongo"
+)
+
+const defaultDeadLettersLimit = 50
+
+var (
+	ErrDeadLetterNotFound = repository.ErrDeadLetterNotFound
+	ErrNotReplayable      = repository.ErrNotReplayable
+)
+
+type DeadLettersUseCase interface {
+	GetDeadLetters(ctx context.Context, request *dto.DeadLettersRequest, span opentracing.Span) (*dto.GetDeadLettersResponse, error)
+	ReplayDeadLetter(ctx context.Context, id string, span opentracing.Span) (*dto.DeadLetterActionResponse, error)
+	DiscardDeadLetter(ctx context.Context, id string, span opentracing.Span) (*dto.DeadLetterActionResponse, error)
+}
+
+type DeadLettersUC struct {
+	rep    repository.DeadLettersRepository
+	logger promtail.Client
+}
+
+func NewDeadLettersUseCases(rep repository.DeadLettersRepository, logger promtail.Client) *DeadLettersUC {
+	return &DeadLettersUC{
+		rep:    rep,
+		logger: logger,
+	}
+}
+
+func (d *DeadLettersUC) GetDeadLetters(ctx context.Context, request *dto.DeadLettersRequest, span opentracing.Span) (*dto.GetDeadLettersResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:GetDeadLetters", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.GetDeadLettersResponse
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultDeadLettersLimit
+	}
+
+	deadLetters, total, err := d.rep.FindDeadLetters(ctx, limit, request.Offset, useCaseSpan)
+	if err != nil {
+		trace.OnError(d.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload = make([]dto.DeadLetterResponse, 0, len(deadLetters))
+	for _, entry := range deadLetters {
+		result.Payload = append(result.Payload, dto.DeadLetterResponse{
+			ID:        entry.ID,
+			Node:      entry.Node,
+			Sequence:  entry.Sequence,
+			Operation: convertOperation(entry.Operation),
+			Payload:   entry.Payload,
+			Attempts:  entry.Attempts,
+			Error:     entry.Error,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	result.Meta.NumOfResults = total
+	result.Meta.PageSize = int(limit)
+
+	return &result, nil
+}
+
+func (d *DeadLettersUC) ReplayDeadLetter(ctx context.Context, id string, span opentracing.Span) (*dto.DeadLetterActionResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:ReplayDeadLetter", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.DeadLetterActionResponse
+
+	if err := d.rep.ReplayDeadLetter(ctx, id, useCaseSpan); err != nil {
+		trace.OnError(d.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload.ID = id
+	result.Payload.Action = "replayed"
+
+	return &result, nil
+}
+
+func (d *DeadLettersUC) DiscardDeadLetter(ctx context.Context, id string, span opentracing.Span) (*dto.DeadLetterActionResponse, error) {
+	tracer := opentracing.GlobalTracer()
+	useCaseSpan := tracer.StartSpan("UCase:DiscardDeadLetter", opentracing.ChildOf(span.Context()))
+	defer useCaseSpan.Finish()
+	var result dto.DeadLetterActionResponse
+
+	if err := d.rep.DeleteDeadLetter(ctx, id, useCaseSpan); err != nil {
+		trace.OnError(d.logger, useCaseSpan, err)
+		return nil, err
+	}
+
+	result.Payload.ID = id
+	result.Payload.Action = "discarded"
+
+	return &result, nil
+}
+
+// convertOperation converts the replication operation to its response.
+func convertOperation(op *models.Operation) *dto.OperationResponse {
+	if op == nil {
+		return nil
+	}
+
+	res := &dto.OperationResponse{
+		Type:      string(op.Type),
+		Method:    string(op.Method),
+		Timestamp: op.Timestamp,
+	}
+
+	if op.Catalog != nil {
+		res.Catalog = &dto.CatalogResponse{
+			ID:       op.Catalog.ID,
+			Active:   op.Catalog.Active,
+			Category: op.Catalog.Category,
+			Name:     op.Catalog.Name,
+			Desc:     op.Catalog.Desc,
+			Value:    op.Catalog.Value,
+			Version:  op.Catalog.Version,
+			Status:   string(op.Catalog.EffectiveStatus()),
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/internal/logger"
+	"github.com/rusrafkasimov/catalogs/internal/queue"
+)
+
+// reconnector is a database client that can reconnect with new settings.
+type reconnector interface {
+	Reconnect(cfg config.Mongo) error
+}
+
+// queueReconfigurer is an event queue that can redial with new settings.
+type queueReconfigurer interface {
+	Reconfigure(cfg config.Queue) error
+}
+
+// reloadSubscriber applies changed settings to running clients. Settings of the HTTP server,
+// tracing, shutdown and replication are applied only after restart.
+func reloadSubscriber(loki *logger.Reloadable, db reconnector, eventQueue queue.EventQueue) config.Subscriber {
+	return func(prev, next *config.AppConfig) {
+		if prev.Loki != next.Loki {
+			loki.Infof("Config: loki settings changed, re-pointing logger")
+			if err := loki.Reconfigure(next.Loki); err != nil {
+				loki.Errorf("Error: can't re-point logger. %s", err.Error())
+			}
+		}
+
+		if prev.Mongo != next.Mongo {
+			loki.Infof("Config: mongo settings changed, reconnecting")
+			if err := db.Reconnect(next.Mongo); err != nil {
+				loki.Errorf("Error: can't reconnect mongo, keeping current client. %s", err.Error())
+			}
+		}
+
+		if prev.Queue != next.Queue {
+			reconfigurer, ok := eventQueue.(queueReconfigurer)
+			if !ok || prev.Queue.Backend != next.Queue.Backend {
+				loki.Warnf("Config: event queue settings changed, restart is required to apply them")
+			} else {
+				loki.Infof("Config: event queue settings changed, redialing")
+				if err := reconfigurer.Reconfigure(next.Queue); err != nil {
+					loki.Errorf("Error: can't redial event queue. %s", err.Error())
+				}
+			}
+		}
+
+		if prev.HTTP != next.HTTP || prev.Jaeger != next.Jaeger || prev.Shutdown != next.Shutdown || prev.Reload != next.Reload ||
+			prev.Outbox != next.Outbox || prev.Replication != next.Replication {
+			loki.Warnf("Config: HTTP, tracing, shutdown, reload, outbox and replication settings changed, restart is required to apply them")
+		}
+	}
+}
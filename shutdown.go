@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"github.com/afiskon/promtail-client/promtail"
+	"github.com/rusrafkasimov/catalogs/internal/config"
+	"github.com/rusrafkasimov/catalogs/internal/queue"
+	"io"
+	"net/http"
+	"time"
+)
+
+// disconnector is a database client that can be disconnected.
+type disconnector interface {
+	Disconnect(ctx context.Context) error
+}
+
+// shutdownResources holds everything that must be released when the service stops.
+type shutdownResources struct {
+	server          *http.Server
+	stopReplication context.CancelFunc
+	replicationDone <-chan struct{}
+	outboxDone      <-chan struct{}
+	changeStream    io.Closer
+	queue           queue.EventQueue
+	mongo           disconnector
+	tracer          io.Closer
+	secrets         config.Provider
+}
+
+// gracefulShutdown drains in-flight HTTP requests and releases resources in order:
+// HTTP server, replicator and outbox relay, change stream, event queue, mongo client, secret provider, loki and jaeger clients.
+// Draining HTTP requests has its own deadline, every following phase has a deadline of its own,
+// so a slow phase doesn't leave the next ones without time.
+func gracefulShutdown(cfg config.Shutdown, logger promtail.Client, res shutdownResources) {
+	logger.Infof("Shutdown: draining HTTP requests (deadline %v)", cfg.DrainTimeout)
+	if res.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+		if err := res.server.Shutdown(ctx); err != nil {
+			logger.Errorf("Shutdown: HTTP server. %s", err.Error())
+		}
+		cancel()
+	}
+
+	logger.Infof("Shutdown: stopping replicator and outbox relay (deadline %v)", cfg.PhaseTimeout)
+	if res.stopReplication != nil {
+		res.stopReplication()
+		deadline := time.NewTimer(cfg.PhaseTimeout)
+		for _, done := range []<-chan struct{}{res.replicationDone, res.outboxDone} {
+			select {
+			case <-done:
+			case <-deadline.C:
+				logger.Errorf("Shutdown: replicator or outbox relay did not stop before deadline")
+			}
+		}
+		deadline.Stop()
+	}
+
+	if res.changeStream != nil {
+		logger.Infof("Shutdown: closing change stream")
+		if err := res.changeStream.Close(); err != nil {
+			logger.Errorf("Shutdown: change stream. %s", err.Error())
+		}
+	}
+
+	logger.Infof("Shutdown: closing event queue")
+	if res.queue != nil {
+		if err := res.queue.Close(); err != nil {
+			logger.Errorf("Shutdown: event queue. %s", err.Error())
+		}
+	}
+
+	logger.Infof("Shutdown: disconnecting mongo (deadline %v)", cfg.PhaseTimeout)
+	if res.mongo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.PhaseTimeout)
+		if err := res.mongo.Disconnect(ctx); err != nil {
+			logger.Errorf("Shutdown: mongo. %s", err.Error())
+		}
+		cancel()
+	}
+
+	if closer, ok := res.secrets.(io.Closer); ok {
+		logger.Infof("Shutdown: closing secret provider")
+		if err := closer.Close(); err != nil {
+			logger.Errorf("Shutdown: secret provider. %s", err.Error())
+		}
+	}
+
+	logger.Infof("Shutdown: flushing loki and jaeger clients")
+	logger.Infof("Catalogs service stopped")
+	logger.Shutdown()
+
+	if res.tracer != nil {
+		_ = res.tracer.Close()
+	}
+}